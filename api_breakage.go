@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// APIBreakageConfig controls the API breaking change check run before
+// publishing a release that is not a major version.
+type APIBreakageConfig struct {
+	Enabled bool `json:"enabled"`
+	// Allow maps product or module names to the breakages accepted without
+	// a major release, by their reported description. "*" accepts every
+	// breakage of that product or module.
+	Allow map[string][]string `json:"allow"`
+}
+
+// APIBreakage is one breaking change reported by
+// swift package diagnose-api-breaking-changes.
+type APIBreakage struct {
+	Module      string `json:"module"`
+	Description string `json:"description"`
+}
+
+var (
+	// 2 breaking changes detected in Kit:
+	apiBreakageHeader = regexp.MustCompile(`^\d+ breaking changes? detected in (.+?):\s*$`)
+	// 💔 API breakage: func Kit.run() has been removed
+	apiBreakageLine = regexp.MustCompile(`API breakage:\s*(.+?)\s*$`)
+)
+
+// ParseAPIBreakages extracts the breakages from the output of
+// swift package diagnose-api-breaking-changes.
+func ParseAPIBreakages(output string) []APIBreakage {
+	var breakages []APIBreakage
+	module := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := apiBreakageHeader.FindStringSubmatch(line); m != nil {
+			module = m[1]
+			continue
+		}
+		if m := apiBreakageLine.FindStringSubmatch(line); m != nil {
+			breakages = append(breakages, APIBreakage{Module: module, Description: m[1]})
+		}
+	}
+	return breakages
+}
+
+// CheckAPIBreakages returns the breakages not accepted by cfg.Allow. An
+// allowlist entry applies to its module, and to every module of the
+// manifest's product of that name. manifest may be nil.
+func CheckAPIBreakages(breakages []APIBreakage, manifest *PackageManifest, cfg APIBreakageConfig) []APIBreakage {
+	var rejected []APIBreakage
+	for _, b := range breakages {
+		names := []string{b.Module}
+		if manifest != nil {
+			for _, product := range manifest.Products {
+				if slices.Contains(product.Targets, b.Module) {
+					names = append(names, product.Name)
+				}
+			}
+		}
+		allowed := slices.ContainsFunc(names, func(name string) bool {
+			allow := cfg.Allow[name]
+			return slices.Contains(allow, "*") || slices.Contains(allow, b.Description)
+		})
+		if !allowed {
+			rejected = append(rejected, b)
+		}
+	}
+	return rejected
+}
+
+// isMajorRelease reports whether a release may break API: a major release,
+// or a minor release of a 0.x version. Without a release type, the version is
+// compared with previous, or with the release context's previous version when
+// previous is empty. It returns false when there is nothing to compare with.
+func isMajorRelease(releaseCtx *plugin.ReleaseContext, previous string) bool {
+	major, minor, _, ok := versionComponents(releaseCtx.Version)
+	if releaseCtx.ReleaseType != "" {
+		return releaseCtx.ReleaseType == "major" || (ok && major == 0 && releaseCtx.ReleaseType == "minor")
+	}
+	if previous == "" {
+		previous = releaseCtx.PreviousVersion
+	}
+	prevMajor, prevMinor, _, prevOK := versionComponents(previous)
+	if !ok || !prevOK {
+		return false
+	}
+	if major != prevMajor {
+		return major > prevMajor
+	}
+	return major == 0 && minor > prevMinor
+}
+
+// previousReleaseTag returns the git tag of the latest release before
+// version, among the tags named tagPrefix followed by a version. Prerelease
+// tags are ignored. It returns "" when there is none.
+func previousReleaseTag(ctx context.Context, workDir, tagPrefix, version string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "tag", "--list", tagPrefix+"*")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list git tags: %w", err)
+	}
+
+	tag, latest := "", ""
+	for _, name := range strings.Fields(string(output)) {
+		v := strings.TrimPrefix(name, tagPrefix)
+		if _, _, _, ok := versionComponents(v); !ok || strings.ContainsAny(v, "-+") {
+			continue
+		}
+		if compareSemanticVersions(v, version) < 0 && (latest == "" || compareSemanticVersions(v, latest) > 0) {
+			tag, latest = name, v
+		}
+	}
+	return tag, nil
+}
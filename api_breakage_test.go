@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const apiBreakageReport = `Building for debugging...
+Build complete! (12.31s)
+Checking for API breaking changes...
+2 breaking changes detected in KitCore:
+  💔 API breakage: func Client.send(_:) has been removed
+  💔 API breakage: var Config.timeout has declared type change from Double to Duration
+No breaking changes detected in KitUI
+1 breaking change detected in KitLabs:
+  💔 API breakage: struct Experiment has been removed
+`
+
+func TestParseAPIBreakages(t *testing.T) {
+	want := []APIBreakage{
+		{Module: "KitCore", Description: "func Client.send(_:) has been removed"},
+		{Module: "KitCore", Description: "var Config.timeout has declared type change from Double to Duration"},
+		{Module: "KitLabs", Description: "struct Experiment has been removed"},
+	}
+	if got := ParseAPIBreakages(apiBreakageReport); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAPIBreakages() = %+v, want %+v", got, want)
+	}
+	if got := ParseAPIBreakages("No breaking changes detected in KitCore\n"); got != nil {
+		t.Errorf("ParseAPIBreakages() without breakages = %+v", got)
+	}
+}
+
+func TestCheckAPIBreakages(t *testing.T) {
+	breakages := ParseAPIBreakages(apiBreakageReport)
+	manifest := &PackageManifest{Products: []Product{
+		{Name: "Kit", Type: ProductType{Kind: ProductLibrary}, Targets: []string{"KitCore", "KitUI"}},
+		{Name: "Labs", Type: ProductType{Kind: ProductLibrary}, Targets: []string{"KitLabs"}},
+	}}
+
+	tests := []struct {
+		name     string
+		manifest *PackageManifest
+		allow    map[string][]string
+		want     []APIBreakage
+	}{
+		{"no allowlist", manifest, nil, breakages},
+		{"product wildcard", manifest, map[string][]string{"Labs": {"*"}}, breakages[:2]},
+		{"module entry", nil, map[string][]string{"KitCore": {"func Client.send(_:) has been removed"}}, breakages[1:]},
+		{"product without manifest", nil, map[string][]string{"Labs": {"*"}}, breakages},
+		{"everything", manifest, map[string][]string{"Kit": {"*"}, "KitLabs": {"*"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckAPIBreakages(breakages, tt.manifest, APIBreakageConfig{Enabled: true, Allow: tt.allow})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckAPIBreakages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMajorRelease(t *testing.T) {
+	tests := []struct {
+		ctx      plugin.ReleaseContext
+		previous string
+		want     bool
+	}{
+		{plugin.ReleaseContext{Version: "2.0.0", ReleaseType: "major"}, "", true},
+		{plugin.ReleaseContext{Version: "1.3.0", ReleaseType: "minor"}, "", false},
+		{plugin.ReleaseContext{Version: "1.2.1", ReleaseType: "patch"}, "", false},
+		{plugin.ReleaseContext{Version: "0.4.0", ReleaseType: "minor"}, "", true},
+		{plugin.ReleaseContext{Version: "0.3.1", ReleaseType: "patch"}, "", false},
+		{plugin.ReleaseContext{Version: "2.0.0", PreviousVersion: "1.9.3"}, "", true},
+		{plugin.ReleaseContext{Version: "1.10.0", PreviousVersion: "1.9.3"}, "", false},
+		{plugin.ReleaseContext{Version: "0.4.0", PreviousVersion: "0.3.2"}, "", true},
+		{plugin.ReleaseContext{Version: "0.3.3", PreviousVersion: "0.3.2"}, "", false},
+		{plugin.ReleaseContext{Version: "1.3.0"}, "1.2.0", false},
+		{plugin.ReleaseContext{Version: "2.0.0"}, "1.2.0", true},
+		{plugin.ReleaseContext{Version: "1.3.0", PreviousVersion: "0.9.0"}, "1.2.0", false},
+		{plugin.ReleaseContext{Version: "1.3.0", PreviousVersion: "next"}, "", false},
+		{plugin.ReleaseContext{Version: "1.0.0"}, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := isMajorRelease(&tt.ctx, tt.previous); got != tt.want {
+			t.Errorf("isMajorRelease(%+v, %q) = %v, want %v", tt.ctx, tt.previous, got, tt.want)
+		}
+	}
+}
+
+// initTaggedRepo creates a git repository in dir with one commit of its
+// files, carrying the given tags.
+func initTaggedRepo(t *testing.T, dir string, tags ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "--allow-empty", "-m", "initial")
+	for _, tag := range tags {
+		git("tag", tag)
+	}
+}
+
+func TestPreviousReleaseTag(t *testing.T) {
+	dir := t.TempDir()
+	initTaggedRepo(t, dir, "v1.2.0", "v1.10.0", "v1.11.0-beta.1", "v2.0.0", "1.12.0", "vnext")
+
+	tests := []struct {
+		prefix  string
+		version string
+		want    string
+	}{
+		{"v", "1.11.0", "v1.10.0"},
+		{"v", "2.1.0", "v2.0.0"},
+		{"v", "1.10.0", "v1.2.0"},
+		{"v", "1.0.0", ""},
+		{"", "1.13.0", "1.12.0"},
+	}
+
+	for _, tt := range tests {
+		got, err := previousReleaseTag(context.Background(), dir, tt.prefix, tt.version)
+		if err != nil {
+			t.Fatalf("previousReleaseTag() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("previousReleaseTag(%q, %q) = %q, want %q", tt.prefix, tt.version, got, tt.want)
+		}
+	}
+}
@@ -2,35 +2,424 @@ package main
 
 import (
 	"archive/zip"
-	"crypto/sha256"
-	"encoding/hex"
+	"cmp"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
 )
 
+// archiveEntry describes a file selected for inclusion in an archive.
+type archiveEntry struct {
+	// path is the location on disk the content is read from.
+	path string
+	// name is the slash-separated path inside the archive.
+	name string
+	// size is the uncompressed size in bytes.
+	size int64
+	// mode is the file mode recorded in the archive.
+	mode os.FileMode
+	// link is the relative symlink target when the entry is stored as a link.
+	link string
+}
+
+// ArchiveReport describes the contents of a created archive.
+type ArchiveReport struct {
+	// Path is the location of the archive file.
+	Path string `json:"path"`
+	// ReportPath is the location of the JSON copy of this report.
+	ReportPath string `json:"-"`
+	// ChecksumPath is the location of the sha256sum-format checksum file,
+	// set once the archive has been saved to an output directory.
+	ChecksumPath string `json:"-"`
+	// Version is the package version the archive was built for.
+	Version string `json:"version"`
+	// Checksum is the SHA-256 of the archive, hex encoded. It matches
+	// `swift package compute-checksum` output.
+	Checksum string `json:"checksum"`
+	// Digests holds the SHA-256 and any additional configured digests of
+	// the archive, hex encoded and keyed by algorithm.
+	Digests Digests `json:"digests"`
+	// Size is the size of the archive file in bytes.
+	Size int64 `json:"size"`
+	// FileCount is the number of entries in the archive.
+	FileCount int `json:"file_count"`
+	// TotalSize is the sum of the uncompressed entry sizes.
+	TotalSize int64 `json:"total_size"`
+	// TotalCompressedSize is the sum of the compressed entry sizes.
+	TotalCompressedSize int64 `json:"total_compressed_size"`
+	// Largest lists the largest entries by uncompressed size.
+	Largest []ArchiveReportEntry `json:"largest"`
+	// Entries lists every entry in archive order.
+	Entries []ArchiveReportEntry `json:"entries"`
+	// Archives lists the additional source archives built from the same
+	// entries, such as tar.gz.
+	Archives []ArchiveFile `json:"archives,omitempty"`
+
+	// persisted is set once the archive has been saved with SaveTo.
+	persisted bool
+}
+
+// ArchiveReportEntry describes a single archive entry.
+type ArchiveReportEntry struct {
+	Path           string `json:"path"`
+	Size           int64  `json:"size"`
+	CompressedSize int64  `json:"compressed_size"`
+	SHA256         string `json:"sha256"`
+}
+
+// largestEntriesCount is the number of entries listed in ArchiveReport.Largest.
+const largestEntriesCount = 10
+
 // CreateArchive creates a package archive for publishing.
-// Returns the path to the archive file, its SHA256 checksum, and any error.
-func CreateArchive(sourceDir, version string, cfg ArchiveConfig) (string, string, error) {
+// Entries are compressed concurrently but always written in walk order, so
+// the same tree produces the same archive. The returned report lists every
+// entry with its size, compressed size and SHA-256, and is also written as
+// JSON next to the archive. Cancelling ctx stops the walk and compression and
+// removes the partial archive.
+func CreateArchive(ctx context.Context, sourceDir, version string, cfg ArchiveConfig) (*ArchiveReport, error) {
+	entries, err := collectArchiveEntries(ctx, sourceDir, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+
 	// Create temp file for archive
 	archiveFile, err := os.CreateTemp("", "swift-package-*.zip")
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create temp file: %w", err)
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
 	}
 	archivePath := archiveFile.Name()
 
-	zipWriter := zip.NewWriter(archiveFile)
+	// Hash the archive as it is written so it never has to be read back
+	digester := newDigester(cfg.Digests)
+	counter := &countingWriter{w: io.MultiWriter(archiveFile, digester)}
+	zipWriter := zip.NewWriter(counter)
+
+	headers, digests, err := writeEntries(ctx, zipWriter, entries, compressionWorkers(cfg), func() error {
+		if cfg.MaxArchiveSize > 0 && counter.n > cfg.MaxArchiveSize {
+			return fmt.Errorf("archive is %s, exceeding max_archive_size of %s",
+				formatBytes(counter.n), formatBytes(cfg.MaxArchiveSize))
+		}
+		return nil
+	})
+	if err != nil {
+		_ = zipWriter.Close()
+		_ = archiveFile.Close()
+		_ = os.Remove(archivePath)
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		_ = archiveFile.Close()
+		_ = os.Remove(archivePath)
+		return nil, fmt.Errorf("failed to close zip writer: %w", err)
+	}
+
+	if cfg.MaxArchiveSize > 0 && counter.n > cfg.MaxArchiveSize {
+		_ = archiveFile.Close()
+		_ = os.Remove(archivePath)
+		return nil, fmt.Errorf("failed to create archive: archive is %s, exceeding max_archive_size of %s",
+			formatBytes(counter.n), formatBytes(cfg.MaxArchiveSize))
+	}
+
+	archiveDigests := digester.Sum()
+
+	if err := archiveFile.Close(); err != nil {
+		_ = os.Remove(archivePath)
+		return nil, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	report := buildArchiveReport(archivePath, version, archiveDigests, counter.n, headers, digests)
+
+	// Build the source tarballs from the same entries
+	basePath := strings.TrimSuffix(archivePath, ".zip")
+	for _, format := range tarFormats(cfg) {
+		path := basePath + "." + format
+		tarDigests, size, err := writeTarArchive(ctx, path, format, entries, cfg.Digests)
+		if err == nil && cfg.MaxArchiveSize > 0 && size > cfg.MaxArchiveSize {
+			_ = os.Remove(path)
+			err = fmt.Errorf("%s archive is %s, exceeding max_archive_size of %s",
+				format, formatBytes(size), formatBytes(cfg.MaxArchiveSize))
+		}
+		if err != nil {
+			report.Remove()
+			return nil, fmt.Errorf("failed to create archive: %w", err)
+		}
+		report.Archives = append(report.Archives, ArchiveFile{
+			Format:   format,
+			Path:     path,
+			Checksum: tarDigests.Checksum(),
+			Digests:  tarDigests,
+			Size:     size,
+		})
+	}
+
+	report.ReportPath = basePath + ".contents.json"
+	if err := WriteArchiveReport(report, report.ReportPath); err != nil {
+		report.Remove()
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// buildArchiveReport assembles a report from the written zip headers.
+func buildArchiveReport(path, version string, archiveDigests Digests, size int64, headers []*zip.FileHeader, digests []string) *ArchiveReport {
+	report := &ArchiveReport{
+		Path:      path,
+		Version:   version,
+		Checksum:  archiveDigests.Checksum(),
+		Digests:   archiveDigests,
+		Size:      size,
+		FileCount: len(headers),
+		Entries:   make([]ArchiveReportEntry, 0, len(headers)),
+	}
+
+	for i, header := range headers {
+		entry := ArchiveReportEntry{
+			Path:           header.Name,
+			Size:           int64(header.UncompressedSize64),
+			CompressedSize: int64(header.CompressedSize64),
+			SHA256:         digests[i],
+		}
+		report.Entries = append(report.Entries, entry)
+		report.TotalSize += entry.Size
+		report.TotalCompressedSize += entry.CompressedSize
+	}
+
+	report.Largest = slices.Clone(report.Entries)
+	slices.SortStableFunc(report.Largest, func(a, b ArchiveReportEntry) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+	if len(report.Largest) > largestEntriesCount {
+		report.Largest = report.Largest[:largestEntriesCount]
+	}
+
+	return report
+}
+
+// WriteArchiveReport writes the report as indented JSON to path.
+func WriteArchiveReport(report *ArchiveReport, path string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write archive report: %w", err)
+	}
+	return nil
+}
+
+// Summary returns a one-line description of the archive contents.
+func (r *ArchiveReport) Summary() string {
+	summary := fmt.Sprintf("%d files, %s uncompressed, %s archive, sha256 %s",
+		r.FileCount, formatBytes(r.TotalSize), formatBytes(r.Size), r.Checksum)
+	for _, archive := range r.Archives {
+		summary += fmt.Sprintf("; %s %s, sha256 %s", archive.Format, formatBytes(archive.Size), archive.Checksum)
+	}
+	return summary
+}
+
+// Outputs returns the report summary in the form used for ExecuteResponse
+// outputs. File paths are only included once the archive has been saved.
+func (r *ArchiveReport) Outputs() map[string]any {
+	outputs := map[string]any{
+		"checksum":              r.Checksum,
+		"digests":               r.Digests,
+		"size":                  r.Size,
+		"file_count":            r.FileCount,
+		"total_size":            r.TotalSize,
+		"total_compressed_size": r.TotalCompressedSize,
+		"largest":               r.Largest,
+	}
+	if r.persisted {
+		outputs["path"] = r.Path
+		outputs["checksum_path"] = r.ChecksumPath
+		outputs["report_path"] = r.ReportPath
+	}
+	if len(r.Archives) > 0 {
+		archives := make([]map[string]any, 0, len(r.Archives))
+		for _, archive := range r.Archives {
+			output := map[string]any{
+				"format":   archive.Format,
+				"checksum": archive.Checksum,
+				"digests":  archive.Digests,
+				"size":     archive.Size,
+			}
+			if r.persisted {
+				output["path"] = archive.Path
+				output["checksum_path"] = archive.ChecksumPath
+			}
+			archives = append(archives, output)
+		}
+		outputs["archives"] = archives
+	}
+	return outputs
+}
+
+// Artifacts returns the saved archive files as release artifacts.
+func (r *ArchiveReport) Artifacts() []plugin.Artifact {
+	if !r.persisted {
+		return nil
+	}
+
+	artifacts := []plugin.Artifact{{
+		Name:     filepath.Base(r.Path),
+		Path:     r.Path,
+		Type:     "file",
+		Size:     r.Size,
+		Checksum: r.Checksum,
+	}}
+	for _, path := range []string{r.ChecksumPath, r.ReportPath} {
+		artifact := plugin.Artifact{Name: filepath.Base(path), Path: path, Type: "file"}
+		if size, err := GetArchiveSize(path); err == nil {
+			artifact.Size = size
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	for _, archive := range r.Archives {
+		artifacts = append(artifacts, archive.artifacts()...)
+	}
+	return artifacts
+}
+
+// SaveTo moves the archive into dir as <baseName>.zip and writes the
+// <baseName>.zip.sha256 checksum file and <baseName>.contents.json report
+// next to it. Additional archives are saved the same way under their own
+// extensions. Saved files are kept by Remove.
+func (r *ArchiveReport) SaveTo(dir, baseName string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	archivePath := filepath.Join(dir, baseName+".zip")
+	if err := moveFile(r.Path, archivePath); err != nil {
+		return fmt.Errorf("failed to save archive: %w", err)
+	}
+	if r.ReportPath != "" {
+		_ = os.Remove(r.ReportPath)
+	}
+	r.Path = archivePath
+	r.persisted = true
+
+	r.ChecksumPath = archivePath + ".sha256"
+	if err := WriteChecksumFile(r.ChecksumPath, r.Checksum, filepath.Base(archivePath)); err != nil {
+		return err
+	}
+
+	for i := range r.Archives {
+		archive := &r.Archives[i]
+		path := filepath.Join(dir, baseName+"."+archive.Format)
+		if err := moveFile(archive.Path, path); err != nil {
+			return fmt.Errorf("failed to save %s archive: %w", archive.Format, err)
+		}
+		archive.Path = path
+		archive.ChecksumPath = path + ".sha256"
+		if err := WriteChecksumFile(archive.ChecksumPath, archive.Checksum, filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+
+	r.ReportPath = filepath.Join(dir, baseName+".contents.json")
+	return WriteArchiveReport(r, r.ReportPath)
+}
+
+// Remove deletes the temporary archive and its report file. Archives saved
+// with SaveTo are left in place.
+func (r *ArchiveReport) Remove() {
+	if r.persisted {
+		return
+	}
+	_ = os.Remove(r.Path)
+	for _, archive := range r.Archives {
+		_ = os.Remove(archive.Path)
+	}
+	if r.ReportPath != "" {
+		_ = os.Remove(r.ReportPath)
+	}
+}
+
+// ArchiveBaseName returns the release file name, without extension, for a
+// package archive: <scope>.<name>-<version>.
+func ArchiveBaseName(scope, name, version string) string {
+	if scope == "" {
+		return fmt.Sprintf("%s-%s", name, version)
+	}
+	return fmt.Sprintf("%s.%s-%s", scope, name, version)
+}
+
+// WriteChecksumFile writes a checksum in sha256sum format.
+func WriteChecksumFile(path, checksum, fileName string) error {
+	line := fmt.Sprintf("%s  %s\n", checksum, fileName)
+	if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
+	}
+	return nil
+}
+
+// moveFile renames src to dst, falling back to copy and delete when they are
+// on different filesystems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+
+	_ = in.Close()
+	return os.Remove(src)
+}
+
+// collectArchiveEntries walks sourceDir and returns the files to archive in
+// walk order. Symlinks are resolved and must stay inside sourceDir; sockets,
+// devices and named pipes are skipped.
+func collectArchiveEntries(ctx context.Context, sourceDir string, cfg ArchiveConfig) ([]archiveEntry, error) {
+	root, err := filepath.EvalSymlinks(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
 
 	// Walk source directory
-	err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// Get relative path
-		relPath, err := filepath.Rel(sourceDir, path)
+		relPath, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
@@ -53,45 +442,143 @@ func CreateArchive(sourceDir, version string, cfg ArchiveConfig) (string, string
 			return nil
 		}
 
-		// Add file to archive
-		return addFileToZip(zipWriter, path, relPath)
+		name := filepath.ToSlash(relPath)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			entry, err := resolveSymlinkEntry(root, path, relPath, cfg)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		}
+
+		// Skip sockets, devices, named pipes and other special files
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if cfg.MaxFileSize > 0 && info.Size() > cfg.MaxFileSize {
+			return fmt.Errorf("file %s is %s, exceeding max_file_size of %s",
+				name, formatBytes(info.Size()), formatBytes(cfg.MaxFileSize))
+		}
+
+		entry := archiveEntry{
+			path: path,
+			name: name,
+			size: info.Size(),
+			mode: info.Mode(),
+		}
+		if substitute, ok := cfg.Substitutes[name]; ok {
+			substituteInfo, err := os.Stat(substitute)
+			if err != nil {
+				return fmt.Errorf("substitute for %s: %w", name, err)
+			}
+			entry.path, entry.size = substitute, substituteInfo.Size()
+		}
+		entries = append(entries, entry)
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
 
+// writeSubstitute writes content to a temporary file to be archived in
+// place of original, with the original's mode and modification time so the
+// archive entry differs only in content. The caller removes the file.
+func writeSubstitute(original string, content []byte) (string, error) {
+	info, err := os.Stat(original)
 	if err != nil {
-		_ = zipWriter.Close()
-		_ = archiveFile.Close()
-		_ = os.Remove(archivePath)
-		return "", "", fmt.Errorf("failed to create archive: %w", err)
+		return "", err
+	}
+	file, err := os.CreateTemp("", "swift-package-substitute-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := file.Name()
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(path, info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Chtimes(path, info.ModTime(), info.ModTime())
 	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to write substitute for %s: %w", filepath.Base(original), err)
+	}
+	return path, nil
+}
 
-	if err := zipWriter.Close(); err != nil {
-		_ = archiveFile.Close()
-		_ = os.Remove(archivePath)
-		return "", "", fmt.Errorf("failed to close zip writer: %w", err)
+// resolveSymlinkEntry turns a symlink inside root into an archive entry.
+// Links that resolve outside root or to an excluded path are rejected.
+// In-tree links are stored as zip symlink entries when PreserveSymlinks is
+// set; otherwise links to files are archived as regular files with the
+// target's content.
+func resolveSymlinkEntry(root, path, relPath string, cfg ArchiveConfig) (archiveEntry, error) {
+	name := filepath.ToSlash(relPath)
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return archiveEntry{}, fmt.Errorf("symlink %s cannot be resolved: %w", name, err)
 	}
 
-	// Calculate checksum
-	if _, err := archiveFile.Seek(0, 0); err != nil {
-		_ = archiveFile.Close()
-		_ = os.Remove(archivePath)
-		return "", "", fmt.Errorf("failed to seek archive: %w", err)
+	targetRel, err := filepath.Rel(root, resolved)
+	if err != nil || !filepath.IsLocal(targetRel) {
+		return archiveEntry{}, fmt.Errorf("symlink %s points outside the package root", name)
 	}
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, archiveFile); err != nil {
-		_ = archiveFile.Close()
-		_ = os.Remove(archivePath)
-		return "", "", fmt.Errorf("failed to calculate checksum: %w", err)
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return archiveEntry{}, fmt.Errorf("symlink %s cannot be resolved: %w", name, err)
 	}
 
-	checksum := hex.EncodeToString(hash.Sum(nil))
+	if shouldExclude(targetRel, cfg.Exclude) {
+		return archiveEntry{}, fmt.Errorf("symlink %s points to excluded path %s", name, filepath.ToSlash(targetRel))
+	}
 
-	if err := archiveFile.Close(); err != nil {
-		_ = os.Remove(archivePath)
-		return "", "", fmt.Errorf("failed to close archive: %w", err)
+	if cfg.PreserveSymlinks {
+		linkDir, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			return archiveEntry{}, err
+		}
+		link, err := filepath.Rel(linkDir, resolved)
+		if err != nil {
+			return archiveEntry{}, err
+		}
+
+		return archiveEntry{
+			path: path,
+			name: name,
+			size: int64(len(link)),
+			mode: os.ModeSymlink | 0777,
+			link: filepath.ToSlash(link),
+		}, nil
+	}
+
+	if info.IsDir() {
+		return archiveEntry{}, fmt.Errorf("symlink %s points to a directory; enable preserve_symlinks to archive it", name)
+	}
+	if !info.Mode().IsRegular() {
+		return archiveEntry{}, fmt.Errorf("symlink %s does not point to a regular file", name)
+	}
+	if cfg.MaxFileSize > 0 && info.Size() > cfg.MaxFileSize {
+		return archiveEntry{}, fmt.Errorf("file %s is %s, exceeding max_file_size of %s",
+			name, formatBytes(info.Size()), formatBytes(cfg.MaxFileSize))
 	}
 
-	return archivePath, checksum, nil
+	return archiveEntry{
+		path: resolved,
+		name: name,
+		size: info.Size(),
+		mode: info.Mode(),
+	}, nil
 }
 
 // shouldExclude checks if a path matches any exclusion pattern.
@@ -127,35 +614,45 @@ func shouldExclude(path string, patterns []string) bool {
 	return false
 }
 
-// addFileToZip adds a file to the zip archive.
-func addFileToZip(zipWriter *zip.Writer, filePath, relativePath string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = file.Close() }()
+// contextReader fails reads once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
 
-	info, err := file.Stat()
-	if err != nil {
-		return err
+// Read implements io.Reader.
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
 	}
+	return c.r.Read(p)
+}
 
-	header, err := zip.FileInfoHeader(info)
-	if err != nil {
-		return err
-	}
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
 
-	// Use forward slashes for zip paths
-	header.Name = strings.ReplaceAll(relativePath, string(filepath.Separator), "/")
-	header.Method = zip.Deflate
+// Write implements io.Writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
 
-	writer, err := zipWriter.CreateHeader(header)
-	if err != nil {
-		return err
+// formatBytes renders a byte count in human-readable binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
-
-	_, err = io.Copy(writer, file)
-	return err
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 // GetArchiveSize returns the size of a file in bytes.
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// spoolMemoryLimit is the uncompressed size above which compressed entry data
+// is buffered in a temporary file instead of memory.
+const spoolMemoryLimit = 8 << 20
+
+// deflaters reuses compressors across entries; each one holds about a
+// megabyte of internal state.
+var deflaters = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	},
+}
+
+// compressedEntry is an archive entry compressed ahead of being written.
+type compressedEntry struct {
+	header *zip.FileHeader
+	digest string
+	data   *spool
+	err    error
+}
+
+// spool buffers compressed entry data in memory or, for large entries, in a
+// temporary file.
+type spool struct {
+	buf  bytes.Buffer
+	file *os.File
+}
+
+// newSpool returns a spool suited to an entry of the given uncompressed size.
+func newSpool(size int64) (*spool, error) {
+	if size <= spoolMemoryLimit {
+		return &spool{}, nil
+	}
+	file, err := os.CreateTemp("", "swift-package-entry-*")
+	if err != nil {
+		return nil, err
+	}
+	return &spool{file: file}, nil
+}
+
+// Write implements io.Writer.
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file != nil {
+		return s.file.Write(p)
+	}
+	return s.buf.Write(p)
+}
+
+// reader returns the buffered data from the beginning.
+func (s *spool) reader() (io.Reader, error) {
+	if s.file != nil {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return s.file, nil
+	}
+	return &s.buf, nil
+}
+
+// cleanup releases the spool's temporary file, if any.
+func (s *spool) cleanup() {
+	if s == nil || s.file == nil {
+		return
+	}
+	_ = s.file.Close()
+	_ = os.Remove(s.file.Name())
+}
+
+// compressionWorkers returns the worker pool size for the configuration.
+func compressionWorkers(cfg ArchiveConfig) int {
+	if cfg.Workers > 0 {
+		return cfg.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// writeEntries compresses entries concurrently on a bounded worker pool and
+// writes them to zipWriter in their original order. At most workers entries
+// are compressed or waiting to be written at any time. afterEach is called
+// after every entry is written and aborts the archive if it returns an error.
+// Cancelling ctx stops dispatching new entries and interrupts running copies.
+func writeEntries(ctx context.Context, zipWriter *zip.Writer, entries []archiveEntry, workers int, afterEach func() error) ([]*zip.FileHeader, []string, error) {
+	results := make([]chan compressedEntry, len(entries))
+	for i := range results {
+		results[i] = make(chan compressedEntry, 1)
+	}
+
+	slots := make(chan struct{}, workers)
+	done := make(chan struct{})
+	dispatched := make(chan struct{})
+	var wg sync.WaitGroup
+
+	go func() {
+		defer close(dispatched)
+		for i, entry := range entries {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] <- compressEntry(ctx, entry)
+			}()
+		}
+	}()
+
+	headers := make([]*zip.FileHeader, 0, len(entries))
+	digests := make([]string, 0, len(entries))
+
+	var err error
+	next := 0
+	for next < len(entries) {
+		var result compressedEntry
+		select {
+		case result = <-results[next]:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			break
+		}
+		next++
+		<-slots
+
+		if result.err == nil {
+			result.err = writeCompressedEntry(zipWriter, result)
+		}
+		result.data.cleanup()
+
+		if result.err != nil {
+			err = result.err
+			break
+		}
+		headers = append(headers, result.header)
+		digests = append(digests, result.digest)
+
+		if afterEach != nil {
+			if err = afterEach(); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		// Stop dispatching and release anything already compressed
+		close(done)
+		<-dispatched
+		wg.Wait()
+		for i := next; i < len(entries); i++ {
+			select {
+			case result := <-results[i]:
+				result.data.cleanup()
+			default:
+			}
+		}
+		return nil, nil, err
+	}
+
+	return headers, digests, nil
+}
+
+// writeCompressedEntry copies pre-compressed entry data into the archive.
+func writeCompressedEntry(zipWriter *zip.Writer, entry compressedEntry) error {
+	writer, err := zipWriter.CreateRaw(entry.header)
+	if err != nil {
+		return err
+	}
+	data, err := entry.data.reader()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, data)
+	return err
+}
+
+// compressEntry reads and compresses a single entry, recording its CRC-32,
+// sizes and SHA-256 in the returned header.
+func compressEntry(ctx context.Context, entry archiveEntry) compressedEntry {
+	if err := ctx.Err(); err != nil {
+		return compressedEntry{err: err}
+	}
+
+	if entry.link != "" {
+		header := &zip.FileHeader{
+			Name:               entry.name,
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE([]byte(entry.link)),
+			CompressedSize64:   uint64(len(entry.link)),
+			UncompressedSize64: uint64(len(entry.link)),
+		}
+		header.SetMode(entry.mode)
+
+		data := &spool{}
+		_, _ = data.buf.WriteString(entry.link)
+		digest := sha256.Sum256([]byte(entry.link))
+		return compressedEntry{header: header, digest: hex.EncodeToString(digest[:]), data: data}
+	}
+
+	header, digest, data, err := compressFile(ctx, entry.path, entry.name)
+	if err != nil {
+		return compressedEntry{err: err}
+	}
+	return compressedEntry{header: header, digest: digest, data: data}
+}
+
+// compressFile deflates a file into a spool.
+func compressFile(ctx context.Context, filePath, name string) (*zip.FileHeader, string, *spool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	data, err := newSpool(info.Size())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	compressed := &countingWriter{w: data}
+	deflater := deflaters.Get().(*flate.Writer)
+	defer deflaters.Put(deflater)
+	deflater.Reset(compressed)
+
+	crc := crc32.NewIEEE()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(deflater, crc, hash), &contextReader{ctx: ctx, r: file})
+	if err == nil {
+		err = deflater.Close()
+	}
+	if err != nil {
+		data.cleanup()
+		return nil, "", nil, err
+	}
+
+	header.CRC32 = crc.Sum32()
+	header.UncompressedSize64 = uint64(size)
+	header.CompressedSize64 = uint64(compressed.n)
+
+	return header, hex.EncodeToString(hash.Sum(nil)), data, nil
+}
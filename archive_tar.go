@@ -0,0 +1,177 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Archive formats supported by archive.formats.
+const (
+	FormatZip    = "zip"
+	FormatTarGz  = "tar.gz"
+	FormatTarZst = "tar.zst"
+)
+
+// archiveFormats lists the supported formats in the order they are built.
+var archiveFormats = []string{FormatZip, FormatTarGz, FormatTarZst}
+
+// ArchiveFile is a source archive built alongside the registry zip from the
+// same file selection.
+type ArchiveFile struct {
+	// Format is the archive format, such as tar.gz.
+	Format string `json:"format"`
+	// Path is the location of the archive file.
+	Path string `json:"path"`
+	// ChecksumPath is the location of the sha256sum-format checksum file,
+	// set once the archive has been saved to an output directory.
+	ChecksumPath string `json:"-"`
+	// Checksum is the SHA-256 of the archive, hex encoded.
+	Checksum string `json:"checksum"`
+	// Digests holds the SHA-256 and any additional configured digests.
+	Digests Digests `json:"digests"`
+	// Size is the size of the archive file in bytes.
+	Size int64 `json:"size"`
+}
+
+// artifacts returns the archive and its checksum file as release artifacts.
+func (f ArchiveFile) artifacts() []plugin.Artifact {
+	artifacts := []plugin.Artifact{{
+		Name:     filepath.Base(f.Path),
+		Path:     f.Path,
+		Type:     "file",
+		Size:     f.Size,
+		Checksum: f.Checksum,
+	}}
+	if f.ChecksumPath != "" {
+		artifact := plugin.Artifact{Name: filepath.Base(f.ChecksumPath), Path: f.ChecksumPath, Type: "file"}
+		if size, err := GetArchiveSize(f.ChecksumPath); err == nil {
+			artifact.Size = size
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts
+}
+
+// isArchiveFormat reports whether format is supported.
+func isArchiveFormat(format string) bool {
+	return slices.Contains(archiveFormats, format)
+}
+
+// tarFormats returns the configured tar formats in build order.
+func tarFormats(cfg ArchiveConfig) []string {
+	var formats []string
+	for _, format := range archiveFormats[1:] {
+		if slices.Contains(cfg.Formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// newTarCompressor returns the compressing writer for a tar format.
+func newTarCompressor(w io.Writer, format string) (io.WriteCloser, error) {
+	switch format {
+	case FormatTarGz:
+		return gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	case FormatTarZst:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// writeTarArchive writes entries to a compressed tarball at path, in the same
+// order and with the same names as the zip archive. It returns the SHA-256
+// and configured digests and size of the written file; the file is removed on
+// error.
+func writeTarArchive(ctx context.Context, path, format string, entries []archiveEntry, algorithms []string) (Digests, int64, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create %s archive: %w", format, err)
+	}
+
+	digester := newDigester(algorithms)
+	counter := &countingWriter{w: io.MultiWriter(file, digester)}
+
+	err = func() error {
+		compressor, err := newTarCompressor(counter, format)
+		if err != nil {
+			return err
+		}
+		err = writeTarEntries(ctx, tar.NewWriter(compressor), entries)
+		if closeErr := compressor.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}()
+	if err == nil {
+		err = file.Close()
+	} else {
+		_ = file.Close()
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, 0, fmt.Errorf("failed to write %s archive: %w", format, err)
+	}
+
+	return digester.Sum(), counter.n, nil
+}
+
+// writeTarEntries adds every entry to a tarball and closes it.
+func writeTarEntries(ctx context.Context, tarWriter *tar.Writer, entries []archiveEntry) error {
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeTarEntry(ctx, tarWriter, entry); err != nil {
+			return fmt.Errorf("failed to add %s: %w", entry.name, err)
+		}
+	}
+	return tarWriter.Close()
+}
+
+// writeTarEntry adds a single file or symlink entry to a tarball.
+func writeTarEntry(ctx context.Context, tarWriter *tar.Writer, entry archiveEntry) error {
+	if entry.link != "" {
+		return tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     entry.name,
+			Linkname: entry.link,
+			Mode:     int64(entry.mode.Perm()),
+		})
+	}
+
+	file, err := os.Open(entry.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Ownership is left out so the tarball does not depend on the build user
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.name,
+		Mode:     int64(info.Mode().Perm()),
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tarWriter, &contextReader{ctx: ctx, r: file}, header.Size)
+	return err
+}
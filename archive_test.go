@@ -1,10 +1,28 @@
 package main
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestCreateArchive(t *testing.T) {
@@ -89,18 +107,18 @@ func TestCreateArchive(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			archivePath, checksum, err := CreateArchive(tempDir, "1.0.0", tt.config)
+			report, err := CreateArchive(context.Background(), tempDir, "1.0.0", tt.config)
 			if err != nil {
 				t.Fatalf("CreateArchive failed: %v", err)
 			}
-			defer func() { _ = os.Remove(archivePath) }()
+			defer report.Remove()
 
-			if checksum == "" {
+			if report.Checksum == "" {
 				t.Error("checksum should not be empty")
 			}
 
 			// Open the archive and verify contents
-			reader, err := zip.OpenReader(archivePath)
+			reader, err := zip.OpenReader(report.Path)
 			if err != nil {
 				t.Fatalf("failed to open archive: %v", err)
 			}
@@ -126,6 +144,295 @@ func TestCreateArchive(t *testing.T) {
 	}
 }
 
+func TestCreateArchive_Report(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift":         "// swift-tools-version:5.7",
+		"Sources/Lib/lib.swift": "public func greet() {}",
+		"Sources/Lib/data.json": strings.Repeat("{}", 1000),
+	})
+
+	report, err := CreateArchive(context.Background(), dir, "1.2.3", ArchiveConfig{})
+	if err != nil {
+		t.Fatalf("CreateArchive failed: %v", err)
+	}
+	defer report.Remove()
+
+	if report.Version != "1.2.3" {
+		t.Errorf("expected version 1.2.3, got %s", report.Version)
+	}
+	checksum, err := ComputeChecksum(report.Path)
+	if err != nil {
+		t.Fatalf("ComputeChecksum failed: %v", err)
+	}
+	if report.Checksum != checksum || report.Digests[DigestSHA256] != checksum {
+		t.Errorf("expected checksum %s, got %s", checksum, report.Checksum)
+	}
+	if report.FileCount != 3 || len(report.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", report.FileCount)
+	}
+
+	size, err := GetArchiveSize(report.Path)
+	if err != nil {
+		t.Fatalf("GetArchiveSize failed: %v", err)
+	}
+	if report.Size != size {
+		t.Errorf("expected report size %d to match archive size %d", report.Size, size)
+	}
+
+	digest := sha256.Sum256([]byte("public func greet() {}"))
+	var total int64
+	for _, entry := range report.Entries {
+		total += entry.Size
+		if entry.CompressedSize == 0 {
+			t.Errorf("%s: compressed size not recorded", entry.Path)
+		}
+		if entry.Path == "Sources/Lib/lib.swift" && entry.SHA256 != hex.EncodeToString(digest[:]) {
+			t.Errorf("%s: unexpected sha256 %s", entry.Path, entry.SHA256)
+		}
+	}
+	if report.TotalSize != total {
+		t.Errorf("expected total size %d, got %d", total, report.TotalSize)
+	}
+	if report.Largest[0].Path != "Sources/Lib/data.json" {
+		t.Errorf("expected data.json to be the largest entry, got %s", report.Largest[0].Path)
+	}
+
+	data, err := os.ReadFile(report.ReportPath)
+	if err != nil {
+		t.Fatalf("report file not written: %v", err)
+	}
+	var written ArchiveReport
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("report file is not valid JSON: %v", err)
+	}
+	if written.Checksum != report.Checksum || len(written.Entries) != len(report.Entries) {
+		t.Error("report file does not match returned report")
+	}
+}
+
+func TestArchiveReport_SaveTo(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift":         "// swift-tools-version:5.7",
+		"Sources/Lib/lib.swift": "public func greet() {}",
+	})
+
+	report, err := CreateArchive(context.Background(), dir, "1.2.3", ArchiveConfig{})
+	if err != nil {
+		t.Fatalf("CreateArchive failed: %v", err)
+	}
+	tempArchive, tempReport := report.Path, report.ReportPath
+
+	outputDir := filepath.Join(t.TempDir(), "release")
+	if err := report.SaveTo(outputDir, ArchiveBaseName("myorg", "Lib", "1.2.3")); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+	report.Remove()
+
+	if report.Path != filepath.Join(outputDir, "myorg.Lib-1.2.3.zip") {
+		t.Errorf("unexpected archive path %s", report.Path)
+	}
+	for _, path := range []string{report.Path, report.ChecksumPath, report.ReportPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+	for _, path := range []string{tempArchive, tempReport} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("expected temporary file %s to be removed", path)
+		}
+	}
+
+	checksumLine, err := os.ReadFile(report.ChecksumPath)
+	if err != nil {
+		t.Fatalf("failed to read checksum file: %v", err)
+	}
+	if string(checksumLine) != report.Checksum+"  myorg.Lib-1.2.3.zip\n" {
+		t.Errorf("unexpected checksum file content %q", string(checksumLine))
+	}
+
+	outputs := report.Outputs()
+	if outputs["path"] != report.Path || outputs["checksum_path"] != report.ChecksumPath {
+		t.Errorf("outputs missing saved paths: %v", outputs)
+	}
+
+	artifacts := report.Artifacts()
+	if len(artifacts) != 3 {
+		t.Fatalf("expected 3 artifacts, got %d", len(artifacts))
+	}
+	if artifacts[0].Checksum != report.Checksum || artifacts[0].Size != report.Size {
+		t.Errorf("unexpected archive artifact %+v", artifacts[0])
+	}
+}
+
+func TestCreateArchive_Formats(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift":         "// swift-tools-version:5.7",
+		"Sources/Lib/lib.swift": "public func greet() {}",
+		"Sources/Lib/data.json": strings.Repeat("{}", 1000),
+	})
+
+	report, err := CreateArchive(context.Background(), dir, "1.2.3", ArchiveConfig{
+		Formats: []string{FormatTarZst, FormatZip, FormatTarGz},
+		Digests: []string{DigestSHA512},
+	})
+	if err != nil {
+		t.Fatalf("CreateArchive failed: %v", err)
+	}
+	defer report.Remove()
+
+	if len(report.Archives) != 2 {
+		t.Fatalf("expected 2 additional archives, got %d", len(report.Archives))
+	}
+	var zipNames []string
+	for _, entry := range report.Entries {
+		zipNames = append(zipNames, entry.Path)
+	}
+
+	for i, format := range []string{FormatTarGz, FormatTarZst} {
+		archive := report.Archives[i]
+		if archive.Format != format {
+			t.Fatalf("expected %s at position %d, got %s", format, i, archive.Format)
+		}
+
+		data, err := os.ReadFile(archive.Path)
+		if err != nil {
+			t.Fatalf("failed to read %s archive: %v", format, err)
+		}
+		digest := sha256.Sum256(data)
+		if archive.Checksum != hex.EncodeToString(digest[:]) || archive.Size != int64(len(data)) {
+			t.Errorf("%s: checksum or size does not match file", format)
+		}
+		digest512 := sha512.Sum512(data)
+		if archive.Digests[DigestSHA512] != hex.EncodeToString(digest512[:]) {
+			t.Errorf("%s: sha-512 digest does not match file", format)
+		}
+		if archive.Checksum == report.Checksum {
+			t.Errorf("%s: checksum should differ from the zip checksum", format)
+		}
+
+		var decompressed io.Reader
+		switch format {
+		case FormatTarGz:
+			decompressed, err = gzip.NewReader(bytes.NewReader(data))
+		case FormatTarZst:
+			decompressed, err = zstd.NewReader(bytes.NewReader(data))
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to decompress: %v", format, err)
+		}
+
+		var names []string
+		tarReader := tar.NewReader(decompressed)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: failed to read tar: %v", format, err)
+			}
+			names = append(names, header.Name)
+			content, err := io.ReadAll(tarReader)
+			if err != nil {
+				t.Fatalf("%s: failed to read %s: %v", format, header.Name, err)
+			}
+			if header.Name == "Sources/Lib/lib.swift" && string(content) != "public func greet() {}" {
+				t.Errorf("%s: unexpected content %q", format, content)
+			}
+		}
+		if !slices.Equal(names, zipNames) {
+			t.Errorf("%s: expected entries %v, got %v", format, zipNames, names)
+		}
+	}
+
+	outputDir := t.TempDir()
+	if err := report.SaveTo(outputDir, "Lib-1.2.3"); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+	for _, name := range []string{"Lib-1.2.3.tar.gz", "Lib-1.2.3.tar.gz.sha256", "Lib-1.2.3.tar.zst", "Lib-1.2.3.tar.zst.sha256"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err != nil {
+			t.Errorf("expected %s to be saved: %v", name, err)
+		}
+	}
+	if artifacts := report.Artifacts(); len(artifacts) != 7 {
+		t.Errorf("expected 7 artifacts, got %d", len(artifacts))
+	}
+}
+
+func TestArchiveBaseName(t *testing.T) {
+	if got := ArchiveBaseName("myorg", "Lib", "1.0.0"); got != "myorg.Lib-1.0.0" {
+		t.Errorf("unexpected base name %s", got)
+	}
+	if got := ArchiveBaseName("", "Lib", "1.0.0"); got != "Lib-1.0.0" {
+		t.Errorf("unexpected base name without scope %s", got)
+	}
+}
+
+func TestCreateArchive_Deterministic(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"Package.swift": "// swift-tools-version:5.7"}
+	for i := range 50 {
+		files[fmt.Sprintf("Sources/Lib/file%02d.swift", i)] = strings.Repeat(fmt.Sprintf("let v%d = %d\n", i, i), i*10)
+	}
+	// Larger than spoolMemoryLimit so the entry is buffered on disk
+	files["Sources/Lib/Resources/large.bin"] = string(randomBytes(t, spoolMemoryLimit+1024))
+	writeTestFiles(t, dir, files)
+
+	var checksums []string
+	for _, workers := range []int{1, 4, 16} {
+		report, err := CreateArchive(context.Background(), dir, "1.0.0", ArchiveConfig{Workers: workers})
+		if err != nil {
+			t.Fatalf("CreateArchive with %d workers failed: %v", workers, err)
+		}
+
+		data, err := os.ReadFile(report.Path)
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		digest := sha256.Sum256(data)
+		if report.Checksum != hex.EncodeToString(digest[:]) {
+			t.Errorf("streamed checksum %s does not match archive content", report.Checksum)
+		}
+
+		reader, err := zip.OpenReader(report.Path)
+		if err != nil {
+			t.Fatalf("failed to open archive: %v", err)
+		}
+		var names []string
+		for _, f := range reader.File {
+			names = append(names, f.Name)
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("failed to open %s: %v", f.Name, err)
+			}
+			content, err := io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", f.Name, err)
+			}
+			if string(content) != files[f.Name] {
+				t.Errorf("%s: content mismatch after round trip", f.Name)
+			}
+		}
+		_ = reader.Close()
+		report.Remove()
+
+		if !slices.IsSorted(names) {
+			t.Errorf("entries not in walk order with %d workers: %v", workers, names)
+		}
+		checksums = append(checksums, report.Checksum)
+	}
+
+	for _, c := range checksums[1:] {
+		if c != checksums[0] {
+			t.Errorf("archive differs between worker counts: %v", checksums)
+		}
+	}
+}
+
 func TestShouldExclude(t *testing.T) {
 	tests := []struct {
 		path     string
@@ -221,3 +528,451 @@ func TestGetArchiveSize(t *testing.T) {
 		t.Errorf("expected size %d, got %d", len(content), size)
 	}
 }
+
+// writeTestFiles creates files with the given contents below dir.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+}
+
+// symlinkOrSkip creates a symlink, skipping the test where that is not permitted.
+func symlinkOrSkip(t *testing.T, target, link string) {
+	t.Helper()
+
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+func TestCreateArchive_Symlinks(t *testing.T) {
+	outside := t.TempDir()
+	writeTestFiles(t, outside, map[string]string{"id_rsa": "secret"})
+
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dir string)
+		config   ArchiveConfig
+		wantErr  string
+		wantLink map[string]string
+		wantFile map[string]string
+	}{
+		{
+			name: "link outside root is rejected",
+			setup: func(t *testing.T, dir string) {
+				symlinkOrSkip(t, filepath.Join(outside, "id_rsa"), filepath.Join(dir, "Sources", "key"))
+			},
+			wantErr: "symlink Sources/key points outside the package root",
+		},
+		{
+			name: "relative traversal link is rejected",
+			setup: func(t *testing.T, dir string) {
+				rel, err := filepath.Rel(filepath.Join(dir, "Sources"), filepath.Join(outside, "id_rsa"))
+				if err != nil {
+					t.Fatalf("failed to compute relative path: %v", err)
+				}
+				symlinkOrSkip(t, rel, filepath.Join(dir, "Sources", "key"))
+			},
+			wantErr: "points outside the package root",
+		},
+		{
+			name: "in-tree file link is archived as a regular file",
+			setup: func(t *testing.T, dir string) {
+				symlinkOrSkip(t, "main.swift", filepath.Join(dir, "Sources", "alias.swift"))
+			},
+			wantFile: map[string]string{"Sources/alias.swift": "print(\"Hello\")"},
+		},
+		{
+			name: "in-tree link is preserved as a symlink entry",
+			setup: func(t *testing.T, dir string) {
+				symlinkOrSkip(t, filepath.Join(dir, "Sources", "main.swift"), filepath.Join(dir, "alias.swift"))
+			},
+			config:   ArchiveConfig{PreserveSymlinks: true},
+			wantLink: map[string]string{"alias.swift": "Sources/main.swift"},
+		},
+		{
+			name: "directory link requires preserve_symlinks",
+			setup: func(t *testing.T, dir string) {
+				symlinkOrSkip(t, "Sources", filepath.Join(dir, "Alias"))
+			},
+			wantErr: "symlink Alias points to a directory",
+		},
+		{
+			name: "preserved link to excluded path is rejected",
+			setup: func(t *testing.T, dir string) {
+				writeTestFiles(t, dir, map[string]string{".build/lib": "binary"})
+				symlinkOrSkip(t, ".build/lib", filepath.Join(dir, "lib"))
+			},
+			config:  ArchiveConfig{PreserveSymlinks: true, Exclude: []string{".build"}},
+			wantErr: "symlink lib points to excluded path .build/lib",
+		},
+		{
+			name: "copied link to excluded path is rejected",
+			setup: func(t *testing.T, dir string) {
+				writeTestFiles(t, dir, map[string]string{".env": "TOKEN=secret"})
+				symlinkOrSkip(t, "../.env", filepath.Join(dir, "Sources", "config.swift"))
+			},
+			config:  ArchiveConfig{Exclude: []string{".env"}},
+			wantErr: "symlink Sources/config.swift points to excluded path .env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{
+				"Package.swift":      "// swift-tools-version:5.7",
+				"Sources/main.swift": "print(\"Hello\")",
+			})
+			tt.setup(t, dir)
+
+			report, err := CreateArchive(context.Background(), dir, "1.0.0", tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateArchive failed: %v", err)
+			}
+			defer report.Remove()
+
+			reader, err := zip.OpenReader(report.Path)
+			if err != nil {
+				t.Fatalf("failed to open archive: %v", err)
+			}
+			defer func() { _ = reader.Close() }()
+
+			contents := make(map[string]string)
+			modes := make(map[string]os.FileMode)
+			for _, f := range reader.File {
+				rc, err := f.Open()
+				if err != nil {
+					t.Fatalf("failed to open %s: %v", f.Name, err)
+				}
+				data, _ := io.ReadAll(rc)
+				_ = rc.Close()
+				contents[f.Name] = string(data)
+				modes[f.Name] = f.Mode()
+			}
+
+			for name, want := range tt.wantFile {
+				if modes[name]&os.ModeSymlink != 0 {
+					t.Errorf("%s stored as symlink, expected regular file", name)
+				}
+				if contents[name] != want {
+					t.Errorf("%s: expected content %q, got %q", name, want, contents[name])
+				}
+			}
+			for name, want := range tt.wantLink {
+				if modes[name]&os.ModeSymlink == 0 {
+					t.Errorf("%s not stored as symlink", name)
+				}
+				if contents[name] != want {
+					t.Errorf("%s: expected link target %q, got %q", name, want, contents[name])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateArchive_SkipsSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"Package.swift": "// swift-tools-version:5.7"})
+
+	listener, err := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	report, err := CreateArchive(context.Background(), dir, "1.0.0", ArchiveConfig{})
+	if err != nil {
+		t.Fatalf("CreateArchive failed: %v", err)
+	}
+	defer report.Remove()
+
+	reader, err := zip.OpenReader(report.Path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	for _, f := range reader.File {
+		if f.Name == "agent.sock" {
+			t.Error("socket should not be archived")
+		}
+	}
+}
+
+func TestCreateArchive_Substitutes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift":         "// swift-tools-version:5.7\n// original\n",
+		"Sources/Lib/lib.swift": "public func greet() {}",
+	})
+
+	substitute, err := writeSubstitute(filepath.Join(dir, "Package.swift"), []byte("// swift-tools-version:5.7\n// rewritten for the registry\n"))
+	if err != nil {
+		t.Fatalf("writeSubstitute failed: %v", err)
+	}
+	defer func() { _ = os.Remove(substitute) }()
+
+	report, err := CreateArchive(context.Background(), dir, "1.0.0", ArchiveConfig{
+		Formats:     []string{"zip", "tar.gz"},
+		Substitutes: map[string]string{"Package.swift": substitute},
+	})
+	if err != nil {
+		t.Fatalf("CreateArchive failed: %v", err)
+	}
+	defer report.Remove()
+
+	reader, err := zip.OpenReader(report.Path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	for _, f := range reader.File {
+		if f.Name != "Package.swift" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if !strings.Contains(string(content), "rewritten for the registry") {
+			t.Errorf("archived Package.swift = %q, want the substitute", content)
+		}
+		if f.Mode().Perm() != 0644 {
+			t.Errorf("archived Package.swift mode = %v, want the original 0644", f.Mode().Perm())
+		}
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(dir, "Package.swift")); !strings.Contains(string(content), "original") {
+		t.Error("working tree Package.swift was modified")
+	}
+}
+
+func TestCreateArchive_SizeLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift":         "// swift-tools-version:5.7",
+		"Sources/Data/big.bin":  strings.Repeat("x", 4096),
+		"Sources/Data/rand.bin": string(randomBytes(t, 8192)),
+	})
+
+	tests := []struct {
+		name    string
+		config  ArchiveConfig
+		wantErr string
+	}{
+		{
+			name:    "file over max_file_size",
+			config:  ArchiveConfig{MaxFileSize: 4000},
+			wantErr: "exceeding max_file_size",
+		},
+		{
+			name:    "archive over max_archive_size",
+			config:  ArchiveConfig{MaxArchiveSize: 4096},
+			wantErr: "exceeding max_archive_size",
+		},
+		{
+			name:   "within limits",
+			config: ArchiveConfig{MaxFileSize: 1 << 20, MaxArchiveSize: 1 << 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CreateArchive(context.Background(), dir, "1.0.0", tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CreateArchive failed: %v", err)
+				}
+				report.Remove()
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// cancelAfterContext cancels itself once Err has been called n times, so a
+// test can interrupt CreateArchive at a chosen checkpoint.
+type cancelAfterContext struct {
+	context.Context
+	cancel    context.CancelFunc
+	remaining atomic.Int64
+	calls     atomic.Int64
+}
+
+func newCancelAfterContext(t *testing.T, n int64) *cancelAfterContext {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	c := &cancelAfterContext{Context: ctx, cancel: cancel}
+	c.remaining.Store(n)
+	return c
+}
+
+// Err implements context.Context.
+func (c *cancelAfterContext) Err() error {
+	c.calls.Add(1)
+	if c.remaining.Add(-1) == 0 {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestCreateArchive_Cancellation(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"Package.swift": "// swift-tools-version:5.7"}
+	for i := range 50 {
+		files[fmt.Sprintf("Sources/Lib/File%02d.swift", i)] = strings.Repeat("let x = 1\n", 100)
+	}
+	writeTestFiles(t, dir, files)
+	big := filepath.Join(dir, "Sources/Lib/Resources/big.bin")
+	if err := os.MkdirAll(filepath.Dir(big), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(big, randomBytes(t, spoolMemoryLimit+1<<20), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	// Count the checkpoints taken while walking the tree
+	counter := newCancelAfterContext(t, -1)
+	if _, err := collectArchiveEntries(counter, dir, ArchiveConfig{}); err != nil {
+		t.Fatalf("collectArchiveEntries failed: %v", err)
+	}
+	walkCalls := counter.calls.Load()
+
+	tests := []struct {
+		name  string
+		after int64
+	}{
+		{name: "before start", after: 1},
+		{name: "during walk", after: walkCalls / 2},
+		{name: "during compression", after: walkCalls + 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+
+			for _, workers := range []int{1, 4} {
+				ctx := newCancelAfterContext(t, tt.after)
+				report, err := CreateArchive(ctx, dir, "1.0.0", ArchiveConfig{Workers: workers})
+				if err == nil {
+					report.Remove()
+					t.Fatalf("workers=%d: expected cancellation error", workers)
+				}
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("workers=%d: expected context.Canceled, got %v", workers, err)
+				}
+			}
+
+			leftover, err := os.ReadDir(tmp)
+			if err != nil {
+				t.Fatalf("failed to read temp dir: %v", err)
+			}
+			for _, entry := range leftover {
+				t.Errorf("temporary file %s left behind", entry.Name())
+			}
+		})
+	}
+}
+
+// randomBytes returns n bytes of incompressible data.
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatalf("failed to generate random data: %v", err)
+	}
+	return buf
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{100 << 20, "100.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.expected {
+			t.Errorf("formatBytes(%d) = %q, expected %q", tt.n, got, tt.expected)
+		}
+	}
+}
+
+// benchmarkPackage creates a package tree with many small sources and a few
+// large resource files.
+func benchmarkPackage(b *testing.B) string {
+	b.Helper()
+
+	dir := b.TempDir()
+	write := func(name string, data []byte) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			b.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	write("Package.swift", []byte("// swift-tools-version:5.7"))
+	for i := range 200 {
+		write(fmt.Sprintf("Sources/Lib/File%03d.swift", i), []byte(strings.Repeat("public func f() -> Int { 42 }\n", 200)))
+	}
+	for i := range 4 {
+		data := make([]byte, 4<<20)
+		if _, err := rand.Read(data[:len(data)/2]); err != nil {
+			b.Fatalf("failed to generate data: %v", err)
+		}
+		write(fmt.Sprintf("Sources/Lib/Resources/bundle%d.bin", i), data)
+	}
+	return dir
+}
+
+func BenchmarkCreateArchive(b *testing.B) {
+	dir := benchmarkPackage(b)
+
+	for _, workers := range []int{1, 4, 0} {
+		name := fmt.Sprintf("workers=%d", workers)
+		if workers == 0 {
+			name = "workers=default"
+		}
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				report, err := CreateArchive(context.Background(), dir, "1.0.0", ArchiveConfig{Workers: workers})
+				if err != nil {
+					b.Fatalf("CreateArchive failed: %v", err)
+				}
+				report.Remove()
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ArchiveValidationError lists the problems found while validating an archive.
+type ArchiveValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *ArchiveValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// ArchiveValidation describes a validated archive.
+type ArchiveValidation struct {
+	// Manifests lists Package.swift and the version-specific manifests
+	// found at the archive root.
+	Manifests []ManifestVariant
+	// Warnings lists what a static reading of the manifest could not cover.
+	Warnings []string
+}
+
+// ValidateArchive extracts a package archive into a temporary directory and
+// verifies that it contains a loadable Swift package: Package.swift must sit
+// at the archive root, the manifest must parse, every version-specific
+// manifest must declare a tools version, and every path the manifest refers
+// to must be present. Without the Swift toolchain the manifest is read
+// statically.
+func ValidateArchive(ctx context.Context, archivePath string) (*ArchiveValidation, error) {
+	tempDir, err := os.MkdirTemp("", "swift-package-validate-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	if err := extractArchive(archivePath, tempDir); err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "Package.swift")); err != nil {
+		if nested := findNestedManifest(tempDir); nested != "" {
+			return nil, &ArchiveValidationError{Problems: []string{
+				fmt.Sprintf("Package.swift found at %s, expected at archive root", nested),
+			}}
+		}
+		return nil, &ArchiveValidationError{Problems: []string{"Package.swift not found at archive root"}}
+	}
+
+	manifests, err := FindManifestVariants(filepath.Join(tempDir, "Package.swift"))
+	if err != nil {
+		return nil, &ArchiveValidationError{Problems: []string{
+			strings.ReplaceAll(err.Error(), tempDir+string(filepath.Separator), ""),
+		}}
+	}
+
+	manifest, warnings, err := LoadManifest(ctx, tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("archived manifest is not loadable: %w", err)
+	}
+
+	validation := &ArchiveValidation{Manifests: manifests, Warnings: warnings}
+	if problems := checkManifestLayout(tempDir, manifest); len(problems) > 0 {
+		return validation, &ArchiveValidationError{Problems: problems}
+	}
+
+	return validation, nil
+}
+
+// checkManifestLayout verifies that the sources, resources and excludes
+// declared by each target exist under root.
+func checkManifestLayout(root string, manifest *PackageManifest) []string {
+	var problems []string
+
+	for _, target := range manifest.Targets {
+		// Test targets are excluded from registry archives by default and
+		// SwiftPM does not load them for dependencies.
+		if target.Type == "test" {
+			continue
+		}
+
+		dirs := target.SourceDirs()
+		if len(dirs) == 0 {
+			continue
+		}
+
+		targetDir := ""
+		for _, dir := range dirs {
+			if pathExists(root, dir) {
+				targetDir = dir
+				break
+			}
+		}
+		if targetDir == "" {
+			problems = append(problems, fmt.Sprintf("target %s: source directory %s not found", target.Name, dirs[0]))
+			continue
+		}
+
+		for _, src := range target.Sources {
+			if !pathExists(root, filepath.Join(targetDir, src)) {
+				problems = append(problems, fmt.Sprintf("target %s: source %s not found", target.Name, src))
+			}
+		}
+		for _, res := range target.Resources {
+			if !pathExists(root, filepath.Join(targetDir, res.Path)) {
+				problems = append(problems, fmt.Sprintf("target %s: resource %s not found", target.Name, res.Path))
+			}
+		}
+		for _, exc := range target.Exclude {
+			if !pathExists(root, filepath.Join(targetDir, exc)) {
+				problems = append(problems, fmt.Sprintf("target %s: excluded path %s not found", target.Name, exc))
+			}
+		}
+	}
+
+	return problems
+}
+
+// pathExists reports whether rel exists under root.
+func pathExists(root, rel string) bool {
+	_, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+	return err == nil
+}
+
+// findNestedManifest returns the relative path of a Package.swift one level
+// below root, or an empty string if there is none.
+func findNestedManifest(root string) string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		rel := filepath.Join(entry.Name(), "Package.swift")
+		if pathExists(root, rel) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return ""
+}
+
+// extractArchive extracts a zip archive into destDir, rejecting entries that
+// would be written outside of it and symlinks that resolve outside of it.
+func extractArchive(archivePath, destDir string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reader.Close() }()
+
+	// Symlinks are created last so that no entry is written through one
+	var links []*zip.File
+	for _, f := range reader.File {
+		if f.Mode()&os.ModeSymlink != 0 {
+			links = append(links, f)
+			continue
+		}
+		if err := extractZipEntry(f, destDir); err != nil {
+			return err
+		}
+	}
+	for _, f := range links {
+		if err := extractZipSymlink(f, destDir); err != nil {
+			return err
+		}
+	}
+
+	// Links may point through each other, so they are resolved once all exist
+	root, err := filepath.EvalSymlinks(destDir)
+	if err != nil {
+		return err
+	}
+	for _, f := range links {
+		resolved, err := filepath.EvalSymlinks(filepath.Join(destDir, filepath.FromSlash(f.Name)))
+		if err != nil {
+			return fmt.Errorf("symlink %s cannot be resolved: %w", f.Name, err)
+		}
+		if rel, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("symlink %s points outside the archive", f.Name)
+		}
+	}
+
+	return nil
+}
+
+// extractZipEntry writes a single zip entry below destDir.
+func extractZipEntry(f *zip.File, destDir string) error {
+	name := filepath.FromSlash(f.Name)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("illegal path in archive: %s", f.Name)
+	}
+	target := filepath.Join(destDir, name)
+
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(target, 0755)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+// maxSymlinkTarget bounds the length of a symlink entry's target.
+const maxSymlinkTarget = 4096
+
+// extractZipSymlink creates the symlink stored in a zip entry below destDir.
+// The target must be relative and lexically stay inside destDir.
+func extractZipSymlink(f *zip.File, destDir string) error {
+	name := filepath.FromSlash(f.Name)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("illegal path in archive: %s", f.Name)
+	}
+	target := filepath.Join(destDir, name)
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(src, maxSymlinkTarget+1))
+	_ = src.Close()
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSymlinkTarget {
+		return fmt.Errorf("symlink %s has an oversized target", f.Name)
+	}
+
+	link := filepath.FromSlash(string(data))
+	if filepath.IsAbs(link) {
+		return fmt.Errorf("symlink %s points outside the archive", f.Name)
+	}
+	if rel, err := filepath.Rel(destDir, filepath.Join(filepath.Dir(target), link)); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("symlink %s points outside the archive", f.Name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(link, target)
+}
+
+// checkArchivedManifests verifies that every manifest variant next to
+// manifestPath was archived.
+func checkArchivedManifests(manifestPath string, archived []ManifestVariant) error {
+	variants, err := FindManifestVariants(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	names := manifestVariantNames(archived)
+	var problems []string
+	for _, variant := range variants {
+		if !slices.Contains(names, variant.Name) {
+			problems = append(problems, fmt.Sprintf("%s is missing from the archive", variant.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return &ArchiveValidationError{Problems: problems}
+	}
+	return nil
+}
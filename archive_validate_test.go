@@ -0,0 +1,329 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTestZip creates a zip archive with the given entries.
+func writeTestZip(t *testing.T, entries map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	zw := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return path
+}
+
+func TestExtractArchive(t *testing.T) {
+	archivePath := writeTestZip(t, map[string]string{
+		"Package.swift":       "// swift-tools-version:5.7",
+		"Sources/Lib/a.swift": "public let a = 1",
+	})
+
+	destDir := t.TempDir()
+	if err := extractArchive(archivePath, destDir); err != nil {
+		t.Fatalf("extractArchive failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "Sources", "Lib", "a.swift"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %v", err)
+	}
+	if string(content) != "public let a = 1" {
+		t.Errorf("unexpected content %q", string(content))
+	}
+}
+
+func TestExtractArchive_RejectsTraversal(t *testing.T) {
+	archivePath := writeTestZip(t, map[string]string{
+		"../evil.txt": "pwned",
+	})
+
+	destDir := t.TempDir()
+	if err := extractArchive(archivePath, destDir); err == nil {
+		t.Fatal("expected error for path traversal entry")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(destDir), "evil.txt")); err == nil {
+		t.Error("traversal entry was written outside destination")
+	}
+}
+
+func TestExtractArchive_RejectsEscapingSymlinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		links [][2]string
+	}{
+		{"absolute target", [][2]string{{"evil", "/etc"}}},
+		{"parent target", [][2]string{{"Sources/evil", "../../outside"}}},
+		{"through another link", [][2]string{{"root", "."}, {"evil", "root/.."}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "archive.zip")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw := zip.NewWriter(file)
+			for _, link := range tt.links {
+				header := &zip.FileHeader{Name: link[0], Method: zip.Store}
+				header.SetMode(os.ModeSymlink | 0777)
+				w, err := zw.CreateHeader(header)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, _ = w.Write([]byte(link[1]))
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			_ = file.Close()
+
+			err = extractArchive(path, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), "points outside the archive") {
+				t.Errorf("expected escaping symlink to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateArchive_PreservedSymlinks(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift": `// swift-tools-version:5.7
+import PackageDescription
+let package = Package(
+    name: "Lib",
+    targets: [
+        .target(name: "Lib"),
+        .target(name: "Shared", sources: ["a.swift"]),
+    ]
+)
+`,
+		"Sources/Lib/a.swift": "public let a = 1",
+	})
+	symlinkOrSkip(t, "Lib", filepath.Join(dir, "Sources", "Shared"))
+
+	report, err := CreateArchive(context.Background(), dir, "1.0.0", ArchiveConfig{PreserveSymlinks: true})
+	if err != nil {
+		t.Fatalf("CreateArchive failed: %v", err)
+	}
+	defer report.Remove()
+
+	if _, err := ValidateArchive(context.Background(), report.Path); err != nil {
+		t.Errorf("ValidateArchive() error = %v", err)
+	}
+}
+
+func TestValidateArchive_ManifestLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		want    string
+	}{
+		{
+			name:    "missing manifest",
+			entries: map[string]string{"Sources/Lib/a.swift": ""},
+			want:    "Package.swift not found at archive root",
+		},
+		{
+			name:    "nested manifest",
+			entries: map[string]string{"MyPackage/Package.swift": ""},
+			want:    "Package.swift found at MyPackage/Package.swift, expected at archive root",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateArchive(context.Background(), writeTestZip(t, tt.entries))
+
+			var validationErr *ArchiveValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ArchiveValidationError, got %v", err)
+			}
+			if validationErr.Error() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, validationErr.Error())
+			}
+		})
+	}
+}
+
+func TestValidateArchive_WithSwift(t *testing.T) {
+	if _, err := exec.LookPath("swift"); err != nil {
+		t.Skip("Swift CLI not available")
+	}
+
+	archivePath := writeTestZip(t, map[string]string{
+		"Package.swift": `// swift-tools-version:5.7
+import PackageDescription
+let package = Package(
+    name: "Lib",
+    targets: [.target(name: "Lib", resources: [.process("Data")])]
+)
+`,
+		"Sources/Lib/a.swift": "public let a = 1",
+	})
+
+	_, err := ValidateArchive(context.Background(), archivePath)
+	if err == nil || !strings.Contains(err.Error(), "resource Data not found") {
+		t.Errorf("expected missing resource error, got %v", err)
+	}
+}
+
+func TestValidateArchive_ManifestVariants(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	const manifest = `// swift-tools-version:5.7
+import PackageDescription
+let package = Package(name: "Lib", targets: [.target(name: "Lib")])
+`
+	entries := map[string]string{
+		"Package.swift":           manifest,
+		"Package@swift-5.9.swift": strings.Replace(manifest, "5.7", "5.9", 1),
+		"Sources/Lib/a.swift":     "public let a = 1",
+	}
+
+	validation, err := ValidateArchive(context.Background(), writeTestZip(t, entries))
+	if err != nil {
+		t.Fatalf("ValidateArchive() error = %v", err)
+	}
+	got := manifestVariantNames(validation.Manifests)
+	if want := []string{"Package.swift", "Package@swift-5.9.swift"}; !slices.Equal(got, want) {
+		t.Errorf("manifests = %v, want %v", got, want)
+	}
+	if validation.Manifests[1].ToolsVersion != "5.9" {
+		t.Errorf("tools version = %q, want 5.9", validation.Manifests[1].ToolsVersion)
+	}
+
+	// A variant in the source tree but not in the archive
+	sourceDir := writeManifests(t, map[string]string{
+		"Package.swift":           manifest,
+		"Package@swift-5.9.swift": entries["Package@swift-5.9.swift"],
+		"Package@swift-6.0.swift": strings.Replace(manifest, "5.7", "6.0", 1),
+	})
+	err = checkArchivedManifests(filepath.Join(sourceDir, "Package.swift"), validation.Manifests)
+	if err == nil || err.Error() != "Package@swift-6.0.swift is missing from the archive" {
+		t.Errorf("checkArchivedManifests() error = %v", err)
+	}
+
+	// An archived variant without a tools version
+	entries["Package@swift-6.0.swift"] = "import PackageDescription\n"
+	_, err = ValidateArchive(context.Background(), writeTestZip(t, entries))
+	if err == nil || err.Error() != "swift-tools-version not found in Package@swift-6.0.swift" {
+		t.Errorf("expected missing tools version error, got %v", err)
+	}
+}
+
+func TestCheckManifestLayout(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"Sources/Core/core.swift",
+		"Sources/Core/Resources/data.json",
+		"Sources/Core/README.md",
+		"Custom/Path/file.swift",
+		"Plugins/Gen/main.swift",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		targets  []Target
+		expected []string
+	}{
+		{
+			name: "conventional layout",
+			targets: []Target{
+				{
+					Name:      "Core",
+					Type:      "regular",
+					Resources: []Resource{{Path: "Resources/data.json"}},
+					Exclude:   []string{"README.md"},
+				},
+				{Name: "Gen", Type: "plugin"},
+			},
+		},
+		{
+			name: "custom path with sources",
+			targets: []Target{
+				{Name: "Custom", Type: "regular", Path: "Custom/Path", Sources: []string{"file.swift"}},
+			},
+		},
+		{
+			name: "test targets and remote binaries are skipped",
+			targets: []Target{
+				{Name: "CoreTests", Type: "test"},
+				{Name: "Remote", Type: "binary"},
+			},
+		},
+		{
+			name: "missing paths",
+			targets: []Target{
+				{
+					Name:      "Core",
+					Type:      "regular",
+					Sources:   []string{"missing.swift"},
+					Resources: []Resource{{Path: "Assets"}},
+					Exclude:   []string{"Legacy"},
+				},
+				{Name: "Other", Type: "executable"},
+			},
+			expected: []string{
+				"target Core: source missing.swift not found",
+				"target Core: resource Assets not found",
+				"target Core: excluded path Legacy not found",
+				"target Other: source directory Sources/Other not found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := checkManifestLayout(root, &PackageManifest{Targets: tt.targets})
+
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tt.expected), len(problems), problems)
+			}
+			for i := range problems {
+				if problems[i] != tt.expected[i] {
+					t.Errorf("expected %q, got %q", tt.expected[i], problems[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// BinaryTargetConfig describes a prebuilt artifact distributed through a
+// `.binaryTarget(name:url:checksum:)` declaration in Package.swift.
+type BinaryTargetConfig struct {
+	// Name is the binary target name in Package.swift.
+	Name string `json:"name"`
+	// Path is the artifact zip, relative to the package root. It may use
+	// the same template fields as URL.
+	Path string `json:"path"`
+	// URL is the download URL template, such as
+	// https://example.com/{{.Tag}}/Foo.xcframework.zip.
+	URL string `json:"url"`
+	// Publish includes the artifact in the release artifacts.
+	Publish bool `json:"publish"`
+}
+
+// BinaryTargetUpdate is the resolved url and checksum for a binary target.
+type BinaryTargetUpdate struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+	Publish  bool   `json:"-"`
+}
+
+// ResolveBinaryTargets renders the configured paths and URLs for a release and
+// computes each artifact's SwiftPM checksum.
+func ResolveBinaryTargets(workDir string, targets []BinaryTargetConfig, data templateData) ([]BinaryTargetUpdate, error) {
+	updates := make([]BinaryTargetUpdate, 0, len(targets))
+	for _, target := range targets {
+		artifactPath, err := renderTemplate("binary_targets.path", target.Path, data)
+		if err != nil {
+			return nil, fmt.Errorf("binary target %s: %w", target.Name, err)
+		}
+		if !filepath.IsAbs(artifactPath) {
+			artifactPath = filepath.Join(workDir, artifactPath)
+		}
+
+		url, err := renderTemplate("binary_targets.url", target.URL, data)
+		if err != nil {
+			return nil, fmt.Errorf("binary target %s: %w", target.Name, err)
+		}
+
+		info, err := os.Stat(artifactPath)
+		if err != nil {
+			return nil, fmt.Errorf("binary target %s: artifact not found: %w", target.Name, err)
+		}
+		checksum, err := ComputeChecksum(artifactPath)
+		if err != nil {
+			return nil, fmt.Errorf("binary target %s: failed to compute checksum: %w", target.Name, err)
+		}
+
+		updates = append(updates, BinaryTargetUpdate{
+			Name:     target.Name,
+			Path:     artifactPath,
+			URL:      url,
+			Checksum: checksum,
+			Size:     info.Size(),
+			Publish:  target.Publish,
+		})
+	}
+	return updates, nil
+}
+
+// UpdateBinaryTargets rewrites the url and checksum arguments of the matching
+// .binaryTarget declarations in Package.swift and its variants. Package.swift,
+// the first variant, must declare every target; the other variants are
+// updated where they declare one. No file is written unless all succeed.
+func UpdateBinaryTargets(variants []ManifestVariant, updates []BinaryTargetUpdate) error {
+	contents := make([][]byte, len(variants))
+	for i, variant := range variants {
+		content, err := os.ReadFile(variant.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", variant.Path, err)
+		}
+		for _, update := range updates {
+			rewritten, err := rewriteBinaryTarget(content, update)
+			if i > 0 && errors.Is(err, errBinaryTargetNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%w in %s", err, variant.Path)
+			}
+			content = rewritten
+		}
+		contents[i] = content
+	}
+
+	for i, variant := range variants {
+		if err := os.WriteFile(variant.Path, contents[i], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", variant.Path, err)
+		}
+	}
+	return nil
+}
+
+// errBinaryTargetNotFound is returned for a binary target the manifest does
+// not declare.
+var errBinaryTargetNotFound = errors.New("not found")
+
+var (
+	binaryTargetCall    = regexp.MustCompile(`\.binaryTarget\s*\(`)
+	binaryTargetURLArg  = regexp.MustCompile(`(\burl\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+	binaryTargetSumArg  = regexp.MustCompile(`(\bchecksum\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+	binaryTargetNameArg = regexp.MustCompile(`\bname\s*:\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+// rewriteBinaryTarget replaces the url and checksum of the binary target
+// named update.Name.
+func rewriteBinaryTarget(content []byte, update BinaryTargetUpdate) ([]byte, error) {
+	for _, loc := range binaryTargetCall.FindAllIndex(content, -1) {
+		start := loc[1]
+		end := closingParen(content, start)
+		if end < 0 {
+			continue
+		}
+		args := content[start:end]
+
+		name := binaryTargetNameArg.FindSubmatch(args)
+		if name == nil || string(name[1]) != update.Name {
+			continue
+		}
+		if !binaryTargetURLArg.Match(args) || !binaryTargetSumArg.Match(args) {
+			return nil, fmt.Errorf("binary target '%s' has no url: and checksum: arguments", update.Name)
+		}
+
+		rewritten := replaceStringArg(binaryTargetURLArg, args, update.URL)
+		rewritten = replaceStringArg(binaryTargetSumArg, rewritten, update.Checksum)
+
+		var out bytes.Buffer
+		out.Write(content[:start])
+		out.Write(rewritten)
+		out.Write(content[end:])
+		return out.Bytes(), nil
+	}
+	return nil, fmt.Errorf("binary target '%s' %w", update.Name, errBinaryTargetNotFound)
+}
+
+// replaceStringArg replaces the string literal value of a labelled argument.
+func replaceStringArg(pattern *regexp.Regexp, args []byte, value string) []byte {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return pattern.ReplaceAllFunc(args, func(match []byte) []byte {
+		sub := pattern.FindSubmatch(match)
+		return append(append(append([]byte{}, sub[1]...), escaped...), sub[3]...)
+	})
+}
+
+// closingParen returns the index of the parenthesis closing the call whose
+// arguments start at start, skipping string literals and comments, or -1.
+func closingParen(content []byte, start int) int {
+	depth := 1
+	for i := start; i < len(content); i++ {
+		switch content[i] {
+		case '"':
+			for i++; i < len(content) && content[i] != '"'; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+		case '/':
+			if i+1 < len(content) && content[i+1] == '/' {
+				for i < len(content) && content[i] != '\n' {
+					i++
+				}
+			} else if i+1 < len(content) && content[i+1] == '*' {
+				end := bytes.Index(content[i+2:], []byte("*/"))
+				if end < 0 {
+					return -1
+				}
+				i += end + 3
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// binaryTargetArtifacts returns the published binary target artifacts.
+func binaryTargetArtifacts(updates []BinaryTargetUpdate) []plugin.Artifact {
+	var artifacts []plugin.Artifact
+	for _, update := range updates {
+		if !update.Publish {
+			continue
+		}
+		artifacts = append(artifacts, plugin.Artifact{
+			Name:     filepath.Base(update.Path),
+			Path:     update.Path,
+			Type:     "file",
+			Size:     update.Size,
+			Checksum: update.Checksum,
+		})
+	}
+	return artifacts
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const binaryTargetManifest = `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    targets: [
+        // The core framework (prebuilt)
+        .binaryTarget(
+            name: "KitCore",
+            url: "https://example.com/1.0.0/KitCore.xcframework.zip",
+            checksum: "0000000000000000000000000000000000000000000000000000000000000000"
+        ),
+        .binaryTarget(name: "KitTool", url: "https://example.com/1.0.0/KitTool.artifactbundle.zip", checksum: "1111"),
+        .binaryTarget(name: "KitLocal", path: "Artifacts/KitLocal.xcframework"),
+        .target(name: "Kit", dependencies: ["KitCore"]),
+    ]
+)
+`
+
+func TestRewriteBinaryTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   BinaryTargetUpdate
+		expected []string
+		wantErr  string
+	}{
+		{
+			name:   "multi-line declaration",
+			update: BinaryTargetUpdate{Name: "KitCore", URL: "https://example.com/2.0.0/KitCore.xcframework.zip", Checksum: "abcd"},
+			expected: []string{
+				`            url: "https://example.com/2.0.0/KitCore.xcframework.zip",`,
+				`            checksum: "abcd"`,
+				`url: "https://example.com/1.0.0/KitTool.artifactbundle.zip", checksum: "1111"`,
+			},
+		},
+		{
+			name:   "single-line declaration",
+			update: BinaryTargetUpdate{Name: "KitTool", URL: "https://example.com/2.0.0/KitTool.artifactbundle.zip", Checksum: "ef01"},
+			expected: []string{
+				`.binaryTarget(name: "KitTool", url: "https://example.com/2.0.0/KitTool.artifactbundle.zip", checksum: "ef01"),`,
+				`checksum: "0000000000000000000000000000000000000000000000000000000000000000"`,
+			},
+		},
+		{
+			name:    "path-based target",
+			update:  BinaryTargetUpdate{Name: "KitLocal", URL: "https://example.com", Checksum: "ef01"},
+			wantErr: "has no url: and checksum: arguments",
+		},
+		{
+			name:    "missing target",
+			update:  BinaryTargetUpdate{Name: "Kit", URL: "https://example.com", Checksum: "ef01"},
+			wantErr: "binary target 'Kit' not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriteBinaryTarget([]byte(binaryTargetManifest), tt.update)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, line := range tt.expected {
+				if !strings.Contains(string(result), line) {
+					t.Errorf("expected manifest to contain %q, got:\n%s", line, result)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveBinaryTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"build/KitCore-2.0.0.xcframework.zip": "hello",
+	})
+
+	updates, err := ResolveBinaryTargets(dir, []BinaryTargetConfig{{
+		Name:    "KitCore",
+		Path:    "build/KitCore-{{.Version}}.xcframework.zip",
+		URL:     "https://github.com/org/kit/releases/download/{{.Tag}}/KitCore.xcframework.zip",
+		Publish: true,
+	}}, templateData{Version: "2.0.0", Tag: "v2.0.0"})
+	if err != nil {
+		t.Fatalf("ResolveBinaryTargets failed: %v", err)
+	}
+
+	if len(updates) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(updates))
+	}
+	update := updates[0]
+	if update.URL != "https://github.com/org/kit/releases/download/v2.0.0/KitCore.xcframework.zip" {
+		t.Errorf("unexpected url %s", update.URL)
+	}
+	if update.Checksum != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected checksum %s", update.Checksum)
+	}
+	if update.Path != filepath.Join(dir, "build/KitCore-2.0.0.xcframework.zip") || update.Size != 5 {
+		t.Errorf("unexpected artifact %s (%d bytes)", update.Path, update.Size)
+	}
+
+	artifacts := binaryTargetArtifacts(updates)
+	if len(artifacts) != 1 || artifacts[0].Checksum != update.Checksum {
+		t.Errorf("unexpected artifacts %+v", artifacts)
+	}
+
+	if _, err := ResolveBinaryTargets(dir, []BinaryTargetConfig{{Name: "Missing", Path: "missing.zip", URL: "x"}}, templateData{}); err == nil {
+		t.Error("expected error for missing artifact")
+	}
+	if _, err := ResolveBinaryTargets(dir, []BinaryTargetConfig{{Name: "Bad", Path: "build/KitCore-2.0.0.xcframework.zip", URL: "{{.Commit}}"}}, templateData{}); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
+
+func TestUpdateBinaryTargets(t *testing.T) {
+	dir := t.TempDir()
+	// The 5.8 variant only declares KitCore; the 5.5 one has no binary targets
+	variant := strings.Replace(binaryTargetManifest, "swift-tools-version:5.9", "swift-tools-version:5.8", 1)
+	variant = strings.Replace(variant, `        .binaryTarget(name: "KitTool", url: "https://example.com/1.0.0/KitTool.artifactbundle.zip", checksum: "1111"),
+`, "", 1)
+	writeTestFiles(t, dir, map[string]string{
+		"Package.swift":           binaryTargetManifest,
+		"Package@swift-5.8.swift": variant,
+		"Package@swift-5.5.swift": "// swift-tools-version:5.5\nimport PackageDescription\nlet package = Package(name: \"Kit\")\n",
+	})
+	variants, err := FindManifestVariants(filepath.Join(dir, "Package.swift"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UpdateBinaryTargets(variants, []BinaryTargetUpdate{
+		{Name: "KitCore", URL: "https://example.com/2.0.0/KitCore.xcframework.zip", Checksum: "aaaa"},
+		{Name: "KitTool", URL: "https://example.com/2.0.0/KitTool.artifactbundle.zip", Checksum: "bbbb"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateBinaryTargets failed: %v", err)
+	}
+
+	for _, name := range []string{"Package.swift", "Package@swift-5.8.swift"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read manifest: %v", err)
+		}
+		if strings.Contains(string(content), "1.0.0") {
+			t.Errorf("old urls left in %s:\n%s", name, content)
+		}
+		if !strings.Contains(string(content), `checksum: "aaaa"`) {
+			t.Errorf("checksums not updated in %s:\n%s", name, content)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "Package.swift")); !strings.Contains(string(content), `checksum: "bbbb"`) {
+		t.Errorf("checksums not updated:\n%s", content)
+	}
+
+	// The first manifest must declare every target
+	err = UpdateBinaryTargets(variants[1:2], []BinaryTargetUpdate{{Name: "KitTool", URL: "https://example.com/3.0.0/KitTool.artifactbundle.zip", Checksum: "cccc"}})
+	if err == nil || !strings.Contains(err.Error(), "binary target 'KitTool' not found") {
+		t.Errorf("expected missing target in the first manifest to fail, got %v", err)
+	}
+}
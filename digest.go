@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Digest algorithms supported by archive.digests, named as in the HTTP
+// Digest and Content-Digest headers.
+const (
+	DigestSHA256 = "sha-256"
+	DigestSHA384 = "sha-384"
+	DigestSHA512 = "sha-512"
+)
+
+// digestAlgorithms lists the supported algorithms in reporting order.
+var digestAlgorithms = []string{DigestSHA256, DigestSHA384, DigestSHA512}
+
+// httpDigestAlgorithms are the algorithms registered for the HTTP Digest
+// (RFC 3230) and Content-Digest (RFC 9530) headers. SHA-384 has no
+// registered token, so it is only reported.
+var httpDigestAlgorithms = []string{DigestSHA256, DigestSHA512}
+
+// newDigestHash returns a hash for a supported algorithm, or nil.
+func newDigestHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case DigestSHA256:
+		return sha256.New()
+	case DigestSHA384:
+		return sha512.New384()
+	case DigestSHA512:
+		return sha512.New()
+	default:
+		return nil
+	}
+}
+
+// isDigestAlgorithm reports whether algorithm is supported.
+func isDigestAlgorithm(algorithm string) bool {
+	return newDigestHash(algorithm) != nil
+}
+
+// Digests maps digest algorithm names to hex-encoded digests. The SHA-256
+// entry is the SwiftPM checksum.
+type Digests map[string]string
+
+// Checksum returns the SHA-256 digest in the format printed by
+// `swift package compute-checksum`.
+func (d Digests) Checksum() string {
+	return d[DigestSHA256]
+}
+
+// DigestHeader returns the RFC 3230 Digest header value, with each digest
+// base64 encoded: sha-256=<base64>,sha-512=<base64>.
+func (d Digests) DigestHeader() (string, error) {
+	return d.header(",", func(algorithm, value string) string {
+		return algorithm + "=" + value
+	})
+}
+
+// ContentDigestHeader returns the RFC 9530 Content-Digest header value,
+// a structured field dictionary: sha-256=:<base64>:, sha-512=:<base64>:.
+func (d Digests) ContentDigestHeader() (string, error) {
+	return d.header(", ", func(algorithm, value string) string {
+		return algorithm + "=:" + value + ":"
+	})
+}
+
+// header formats the HTTP-registered digests with format, joined by sep.
+func (d Digests) header(sep string, format func(algorithm, value string) string) (string, error) {
+	var parts []string
+	for _, algorithm := range httpDigestAlgorithms {
+		digest, ok := d[algorithm]
+		if !ok {
+			continue
+		}
+		raw, err := hex.DecodeString(digest)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s digest %q: %w", algorithm, digest, err)
+		}
+		parts = append(parts, format(algorithm, base64.StdEncoding.EncodeToString(raw)))
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("no %s digest available", DigestSHA256)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// digester computes SHA-256 and any additional configured digests over
+// everything written to it.
+type digester struct {
+	algorithms []string
+	hashes     []hash.Hash
+	w          io.Writer
+}
+
+// newDigester returns a digester for SHA-256 plus the given algorithms.
+// Unsupported algorithms are ignored; Validate reports them.
+func newDigester(algorithms []string) *digester {
+	d := &digester{}
+	for _, algorithm := range digestAlgorithms {
+		if algorithm != DigestSHA256 && !slices.Contains(algorithms, algorithm) {
+			continue
+		}
+		d.algorithms = append(d.algorithms, algorithm)
+		d.hashes = append(d.hashes, newDigestHash(algorithm))
+	}
+
+	writers := make([]io.Writer, len(d.hashes))
+	for i, h := range d.hashes {
+		writers[i] = h
+	}
+	d.w = io.MultiWriter(writers...)
+	return d
+}
+
+// Write implements io.Writer.
+func (d *digester) Write(p []byte) (int, error) {
+	return d.w.Write(p)
+}
+
+// Sum returns the hex-encoded digests of the data written so far.
+func (d *digester) Sum() Digests {
+	digests := make(Digests, len(d.hashes))
+	for i, h := range d.hashes {
+		digests[d.algorithms[i]] = hex.EncodeToString(h.Sum(nil))
+	}
+	return digests
+}
+
+// ComputeChecksum returns the SwiftPM checksum of a file: the lowercase hex
+// SHA-256, identical to `swift package compute-checksum` output and suitable
+// for `.binaryTarget(url:checksum:)`.
+func ComputeChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
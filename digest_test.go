@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDigests_Headers(t *testing.T) {
+	// Digests of "hello"
+	digests := Digests{
+		DigestSHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		DigestSHA384: "59e1748777448c69de6b800d7a33bbfb9ff1b463e44354c3553bcdb9c666fa90125a3c79f90397bdf5f6a13de828684f",
+		DigestSHA512: "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043",
+	}
+
+	digestHeader, err := digests.DigestHeader()
+	if err != nil {
+		t.Fatalf("DigestHeader failed: %v", err)
+	}
+	expected := "sha-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=," +
+		"sha-512=m3HSJL1i83hdltRq0+o9czGb+8KJDKra4t/3JRlnPKcjI8PZm6XBHXx6zG4UuMXaDEZjR1wuXDre9G9zvN7AQw=="
+	if digestHeader != expected {
+		t.Errorf("unexpected Digest header:\n got %s\nwant %s", digestHeader, expected)
+	}
+
+	contentDigest, err := digests.ContentDigestHeader()
+	if err != nil {
+		t.Fatalf("ContentDigestHeader failed: %v", err)
+	}
+	expected = "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:, " +
+		"sha-512=:m3HSJL1i83hdltRq0+o9czGb+8KJDKra4t/3JRlnPKcjI8PZm6XBHXx6zG4UuMXaDEZjR1wuXDre9G9zvN7AQw==:"
+	if contentDigest != expected {
+		t.Errorf("unexpected Content-Digest header:\n got %s\nwant %s", contentDigest, expected)
+	}
+
+	if _, err := (Digests{DigestSHA256: "not-hex"}).DigestHeader(); err == nil {
+		t.Error("expected error for invalid hex digest")
+	}
+	if _, err := (Digests{}).DigestHeader(); err == nil {
+		t.Error("expected error without a SHA-256 digest")
+	}
+}
+
+func TestNewDigester(t *testing.T) {
+	data := []byte(strings.Repeat("swift", 1000))
+	sum256 := sha256.Sum256(data)
+	sum384 := sha512.Sum384(data)
+	sum512 := sha512.Sum512(data)
+
+	tests := []struct {
+		name       string
+		algorithms []string
+		expected   Digests
+	}{
+		{
+			name:     "sha-256 only by default",
+			expected: Digests{DigestSHA256: hex.EncodeToString(sum256[:])},
+		},
+		{
+			name:       "additional digests",
+			algorithms: []string{DigestSHA512, DigestSHA384, "md5"},
+			expected: Digests{
+				DigestSHA256: hex.EncodeToString(sum256[:]),
+				DigestSHA384: hex.EncodeToString(sum384[:]),
+				DigestSHA512: hex.EncodeToString(sum512[:]),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDigester(tt.algorithms)
+			if _, err := d.Write(data); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			got := d.Sum()
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d digests, got %v", len(tt.expected), got)
+			}
+			for algorithm, digest := range tt.expected {
+				if got[algorithm] != digest {
+					t.Errorf("%s: expected %s, got %s", algorithm, digest, got[algorithm])
+				}
+			}
+		})
+	}
+}
+
+func TestComputeChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "artifact.zip")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	// Matches `swift package compute-checksum artifact.zip`
+	checksum, err := ComputeChecksum(path)
+	if err != nil {
+		t.Fatalf("ComputeChecksum failed: %v", err)
+	}
+	if checksum != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected checksum %s", checksum)
+	}
+
+	if _, err := ComputeChecksum(filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -2,7 +2,10 @@ module github.com/relicta-tech/plugin-swift-pm
 
 go 1.24.0
 
-require github.com/relicta-tech/relicta-plugin-sdk v1.0.0
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/relicta-tech/relicta-plugin-sdk v1.0.0
+)
 
 require (
 	github.com/fatih/color v1.7.0 // indirect
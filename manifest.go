@@ -10,41 +10,548 @@ import (
 	"strings"
 )
 
-// PackageManifest represents parsed Package.swift content.
+// PackageManifest is the package description printed by
+// `swift package dump-package`. Unmarshalling accepts the output of SwiftPM
+// 5.5 through 6.x; enum-shaped fields are flattened into plain structs, and
+// that flattened form, as marshalled, is accepted too.
 type PackageManifest struct {
-	Name         string       `json:"name"`
-	Platforms    []Platform   `json:"platforms"`
-	Products     []Product    `json:"products"`
-	Dependencies []Dependency `json:"dependencies"`
-	Targets      []Target     `json:"targets"`
-	SwiftVersion string       `json:"swift_tools_version"`
+	Name                  string                 `json:"name"`
+	ToolsVersion          ToolsVersion           `json:"toolsVersion"`
+	Platforms             []Platform             `json:"platforms"`
+	Products              []Product              `json:"products"`
+	Dependencies          []Dependency           `json:"dependencies"`
+	Targets               []Target               `json:"targets"`
+	SwiftLanguageVersions []SwiftLanguageVersion `json:"swiftLanguageVersions,omitempty"`
+	CLanguageStandard     string                 `json:"cLanguageStandard,omitempty"`
+	CxxLanguageStandard   string                 `json:"cxxLanguageStandard,omitempty"`
+}
+
+// ToolsVersion is the swift-tools-version the manifest declares.
+type ToolsVersion struct {
+	Version string `json:"_version"`
+}
+
+// String returns the version, such as 5.9.0.
+func (v ToolsVersion) String() string {
+	return v.Version
+}
+
+// SwiftLanguageVersion is an entry of swiftLanguageVersions (swiftLanguageModes
+// in 6.0 manifests), such as "5" or "6".
+type SwiftLanguageVersion string
+
+// UnmarshalJSON accepts a plain string, a {"rawValue": ...} object or an
+// enum case such as {"v5": {}} or {"version": {"_0": "4.2"}}.
+func (v *SwiftLanguageVersion) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = SwiftLanguageVersion(s)
+		return nil
+	}
+
+	var raw struct {
+		RawValue string `json:"rawValue"`
+	}
+	if err := json.Unmarshal(data, &raw); err == nil && raw.RawValue != "" {
+		*v = SwiftLanguageVersion(raw.RawValue)
+		return nil
+	}
+
+	kind, payload, err := decodeEnumCase(data)
+	if err != nil {
+		return fmt.Errorf("invalid swift language version: %w", err)
+	}
+	if values := enumStrings(payload); len(values) > 0 {
+		*v = SwiftLanguageVersion(values[0])
+	} else {
+		*v = SwiftLanguageVersion(strings.TrimPrefix(kind, "v"))
+	}
+	return nil
 }
 
 // Platform represents a supported platform.
 type Platform struct {
-	Name    string `json:"platformName"`
-	Version string `json:"version"`
+	Name    string   `json:"platformName"`
+	Version string   `json:"version"`
+	Options []string `json:"options,omitempty"`
 }
 
 // Product represents a package product.
 type Product struct {
-	Name    string   `json:"name"`
-	Type    string   `json:"type"`
-	Targets []string `json:"targets"`
+	Name    string      `json:"name"`
+	Type    ProductType `json:"type"`
+	Targets []string    `json:"targets"`
 }
 
+// Product kinds.
+const (
+	ProductLibrary    = "library"
+	ProductExecutable = "executable"
+	ProductPlugin     = "plugin"
+	ProductSnippet    = "snippet"
+	ProductTest       = "test"
+	ProductMacro      = "macro"
+)
+
+// ProductType is the kind of a product and, for libraries, their linkage.
+type ProductType struct {
+	// Kind is one of the Product* constants.
+	Kind string `json:"kind"`
+	// Linkage is automatic, static or dynamic for libraries.
+	Linkage string `json:"linkage,omitempty"`
+}
+
+// UnmarshalJSON accepts the {"library": ["automatic"]} and {"executable":
+// null} enum encoding used by dump-package.
+func (t *ProductType) UnmarshalJSON(data []byte) error {
+	type plain ProductType
+	if ok, err := decodeFlattened(data, (*plain)(t)); ok {
+		return err
+	}
+
+	kind, payload, err := decodeEnumCase(data)
+	if err != nil {
+		return fmt.Errorf("invalid product type: %w", err)
+	}
+	*t = ProductType{Kind: kind}
+	if kind == ProductLibrary {
+		if values := enumStrings(payload); len(values) > 0 {
+			t.Linkage = values[0]
+		}
+	}
+	return nil
+}
+
+// String returns the kind, with the linkage for non-automatic libraries.
+func (t ProductType) String() string {
+	if t.Linkage != "" && t.Linkage != "automatic" {
+		return t.Kind + "(" + t.Linkage + ")"
+	}
+	return t.Kind
+}
+
+// Dependency kinds.
+const (
+	DependencySourceControl = "sourceControl"
+	DependencyRegistry      = "registry"
+	DependencyFileSystem    = "fileSystem"
+)
+
 // Dependency represents a package dependency.
 type Dependency struct {
-	URL      string `json:"url"`
-	Name     string `json:"name"`
-	Versions string `json:"version"`
+	// Kind is one of the Dependency* constants.
+	Kind string `json:"kind"`
+	// Identity is the package identity SwiftPM derives from the location,
+	// or the scope.name registry identifier.
+	Identity string `json:"identity"`
+	// Name is the explicit name given with .package(name:), if any.
+	Name string `json:"name,omitempty"`
+	// Location is the repository URL or local path. Registry dependencies
+	// have no location.
+	Location string `json:"location,omitempty"`
+	// Requirement is the version requirement; nil for file system
+	// dependencies.
+	Requirement *Requirement `json:"requirement,omitempty"`
+}
+
+// UnmarshalJSON accepts the sourceControl, registry and fileSystem enum
+// cases, and the scm and local cases written by SwiftPM 5.5.
+func (d *Dependency) UnmarshalJSON(data []byte) error {
+	type plain Dependency
+	if ok, err := decodeFlattened(data, (*plain)(d)); ok {
+		return err
+	}
+
+	kind, payload, err := decodeEnumCase(data)
+	if err != nil {
+		return fmt.Errorf("invalid dependency: %w", err)
+	}
+
+	var values []struct {
+		Identity    string          `json:"identity"`
+		Name        string          `json:"nameForTargetDependencyResolutionOnly"`
+		Location    json.RawMessage `json:"location"`
+		Path        string          `json:"path"`
+		Requirement json.RawMessage `json:"requirement"`
+	}
+	if err := json.Unmarshal(payload, &values); err != nil || len(values) == 0 {
+		return fmt.Errorf("invalid %s dependency", kind)
+	}
+	value := values[0]
+
+	switch kind {
+	case DependencySourceControl, "scm":
+		kind = DependencySourceControl
+	case DependencyFileSystem, "local":
+		kind = DependencyFileSystem
+	case DependencyRegistry:
+	default:
+		return fmt.Errorf("unknown dependency kind %q", kind)
+	}
+
+	*d = Dependency{Kind: kind, Identity: value.Identity, Name: value.Name, Location: value.Path}
+	if len(value.Location) > 0 && string(value.Location) != "null" {
+		if d.Location, err = decodeLocation(value.Location); err != nil {
+			return err
+		}
+	}
+	if len(value.Requirement) > 0 && string(value.Requirement) != "null" {
+		d.Requirement = &Requirement{}
+		if err := json.Unmarshal(value.Requirement, d.Requirement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeLocation reads a source control location: a plain string (5.5),
+// {"remote": ["url"]} or {"local": ["path"]} (5.6–5.8), or
+// {"remote": [{"urlString": "url"}]} (5.9 and later).
+func decodeLocation(data []byte) (string, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s, nil
+	}
+
+	_, payload, err := decodeEnumCase(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid dependency location: %w", err)
+	}
+	var urls []struct {
+		URLString string `json:"urlString"`
+	}
+	if err := json.Unmarshal(payload, &urls); err == nil && len(urls) > 0 && urls[0].URLString != "" {
+		return urls[0].URLString, nil
+	}
+	if values := enumStrings(payload); len(values) > 0 {
+		return values[0], nil
+	}
+	return "", fmt.Errorf("invalid dependency location %s", payload)
+}
+
+// Requirement kinds.
+const (
+	RequirementRange    = "range"
+	RequirementExact    = "exact"
+	RequirementBranch   = "branch"
+	RequirementRevision = "revision"
+)
+
+// Requirement is a dependency version requirement.
+type Requirement struct {
+	// Kind is one of the Requirement* constants.
+	Kind string `json:"kind"`
+	// LowerBound and UpperBound delimit a range requirement; the upper
+	// bound is exclusive.
+	LowerBound string `json:"lowerBound,omitempty"`
+	UpperBound string `json:"upperBound,omitempty"`
+	// Value is the exact version, branch name or revision.
+	Value string `json:"value,omitempty"`
+}
+
+// UnmarshalJSON accepts the {"range": [{"lowerBound": ..., "upperBound":
+// ...}]}, {"exact": ["1.0.0"]}, {"branch": ["main"]} and {"revision":
+// ["abc"]} encodings.
+func (r *Requirement) UnmarshalJSON(data []byte) error {
+	type plain Requirement
+	if ok, err := decodeFlattened(data, (*plain)(r)); ok {
+		return err
+	}
+
+	kind, payload, err := decodeEnumCase(data)
+	if err != nil {
+		return fmt.Errorf("invalid requirement: %w", err)
+	}
+	*r = Requirement{Kind: kind}
+
+	if kind == RequirementRange {
+		var bounds []struct {
+			LowerBound string `json:"lowerBound"`
+			UpperBound string `json:"upperBound"`
+		}
+		if err := json.Unmarshal(payload, &bounds); err != nil || len(bounds) == 0 {
+			return fmt.Errorf("invalid range requirement %s", payload)
+		}
+		r.LowerBound, r.UpperBound = bounds[0].LowerBound, bounds[0].UpperBound
+		return nil
+	}
+
+	values := enumStrings(payload)
+	if len(values) == 0 {
+		return fmt.Errorf("invalid %s requirement %s", kind, payload)
+	}
+	r.Value = values[0]
+	return nil
+}
+
+// String formats the requirement as it would read in Package.swift.
+func (r Requirement) String() string {
+	switch r.Kind {
+	case RequirementRange:
+		return r.LowerBound + "..<" + r.UpperBound
+	default:
+		return r.Kind + ": " + r.Value
+	}
 }
 
 // Target represents a package target.
 type Target struct {
-	Name         string   `json:"name"`
-	Type         string   `json:"type"`
-	Dependencies []string `json:"dependencies"`
+	Name              string             `json:"name"`
+	Type              string             `json:"type"`
+	Path              string             `json:"path,omitempty"`
+	URL               string             `json:"url,omitempty"`
+	Checksum          string             `json:"checksum,omitempty"`
+	Exclude           []string           `json:"exclude,omitempty"`
+	Sources           []string           `json:"sources,omitempty"`
+	Resources         []Resource         `json:"resources,omitempty"`
+	PublicHeadersPath string             `json:"publicHeadersPath,omitempty"`
+	Dependencies      []TargetDependency `json:"dependencies"`
+	Settings          []TargetSetting    `json:"settings,omitempty"`
+}
+
+// Resource represents a resource declared by a target.
+type Resource struct {
+	Path string `json:"path"`
+	// Rule is process, copy or embedInCode.
+	Rule string `json:"rule,omitempty"`
+	// Localization is default or base for localized processed resources.
+	Localization string `json:"localization,omitempty"`
+}
+
+// UnmarshalJSON accepts both the string rule with a separate localization
+// written before SwiftPM 5.6 and the {"process": {"localization": ...}}
+// enum encoding used since.
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Path         string          `json:"path"`
+		Rule         json.RawMessage `json:"rule"`
+		Localization string          `json:"localization"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = Resource{Path: raw.Path, Localization: raw.Localization}
+
+	if len(raw.Rule) == 0 || string(raw.Rule) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw.Rule, &r.Rule); err == nil {
+		return nil
+	}
+	kind, payload, err := decodeEnumCase(raw.Rule)
+	if err != nil {
+		return fmt.Errorf("invalid resource rule: %w", err)
+	}
+	r.Rule = kind
+	var options struct {
+		Localization string `json:"localization"`
+	}
+	if json.Unmarshal(payload, &options) == nil && options.Localization != "" {
+		r.Localization = options.Localization
+	}
+	return nil
+}
+
+// Target dependency kinds.
+const (
+	TargetDependencyByName  = "byName"
+	TargetDependencyTarget  = "target"
+	TargetDependencyProduct = "product"
+)
+
+// TargetDependency is a dependency of a target on another target or on a
+// product of a package dependency.
+type TargetDependency struct {
+	// Kind is one of the TargetDependency* constants.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Package is the dependency providing a product, if given.
+	Package string `json:"package,omitempty"`
+	// Platforms limits the dependency to the listed platforms.
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+// UnmarshalJSON accepts the {"byName": ["Name", condition]},
+// {"target": ["Name", condition]} and {"product": ["Name", "package",
+// moduleAliases, condition]} encodings. The module aliases element was
+// added in SwiftPM 5.7.
+func (d *TargetDependency) UnmarshalJSON(data []byte) error {
+	type plain TargetDependency
+	if ok, err := decodeFlattened(data, (*plain)(d)); ok {
+		return err
+	}
+
+	kind, payload, err := decodeEnumCase(data)
+	if err != nil {
+		return fmt.Errorf("invalid target dependency: %w", err)
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(payload, &values); err != nil || len(values) == 0 {
+		return fmt.Errorf("invalid %s target dependency %s", kind, payload)
+	}
+	*d = TargetDependency{Kind: kind}
+	if err := json.Unmarshal(values[0], &d.Name); err != nil {
+		return fmt.Errorf("invalid %s target dependency name: %w", kind, err)
+	}
+
+	rest := values[1:]
+	if kind == TargetDependencyProduct && len(rest) > 0 {
+		_ = json.Unmarshal(rest[0], &d.Package)
+		rest = rest[1:]
+	}
+	// The condition is always the last element
+	if len(rest) > 0 {
+		var condition settingCondition
+		if json.Unmarshal(rest[len(rest)-1], &condition) == nil {
+			d.Platforms = condition.PlatformNames
+		}
+	}
+	return nil
+}
+
+// TargetSetting is a build setting declared by a target.
+type TargetSetting struct {
+	// Tool is c, cxx, swift or linker.
+	Tool string `json:"tool"`
+	// Kind is the setting, such as define, unsafeFlags or linkedLibrary.
+	Kind   string   `json:"kind"`
+	Values []string `json:"values,omitempty"`
+	// Platforms and Configuration restrict when the setting applies.
+	Platforms     []string `json:"platforms,omitempty"`
+	Configuration string   `json:"configuration,omitempty"`
+}
+
+// settingCondition is the condition attached to settings and target
+// dependencies.
+type settingCondition struct {
+	PlatformNames []string `json:"platformNames"`
+	Config        string   `json:"config"`
+}
+
+// UnmarshalJSON accepts the {"name": "define", "value": [...]} form written
+// before SwiftPM 5.9 and the {"kind": {"define": {"_0": ...}}} enum
+// encoding used since.
+func (s *TargetSetting) UnmarshalJSON(data []byte) error {
+	type plain TargetSetting
+	if ok, err := decodeFlattened(data, (*plain)(s)); ok {
+		return err
+	}
+
+	var raw struct {
+		Tool      string            `json:"tool"`
+		Name      string            `json:"name"`
+		Value     []string          `json:"value"`
+		Kind      json.RawMessage   `json:"kind"`
+		Condition *settingCondition `json:"condition"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = TargetSetting{Tool: raw.Tool, Kind: raw.Name, Values: raw.Value}
+	if len(raw.Kind) > 0 && string(raw.Kind) != "null" {
+		kind, payload, err := decodeEnumCase(raw.Kind)
+		if err != nil {
+			return fmt.Errorf("invalid setting kind: %w", err)
+		}
+		s.Kind = kind
+		s.Values = enumStrings(payload)
+	}
+	if raw.Condition != nil {
+		s.Platforms = raw.Condition.PlatformNames
+		s.Configuration = raw.Condition.Config
+	}
+	return nil
+}
+
+// decodeFlattened decodes data into v when it is in the flattened form these
+// types marshal to, an object whose "kind" is a string, and reports whether
+// it was. v must not implement json.Unmarshaler.
+func decodeFlattened(data []byte, v any) (bool, error) {
+	var probe struct {
+		Kind json.RawMessage `json:"kind"`
+	}
+	if json.Unmarshal(data, &probe) != nil || len(probe.Kind) == 0 || probe.Kind[0] != '"' {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// decodeEnumCase splits a Swift enum encoded as a single-key object into the
+// case name and its payload.
+func decodeEnumCase(data []byte) (string, json.RawMessage, error) {
+	var cases map[string]json.RawMessage
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return "", nil, err
+	}
+	if len(cases) != 1 {
+		return "", nil, fmt.Errorf("expected a single enum case, got %s", data)
+	}
+	for kind, payload := range cases {
+		return kind, payload, nil
+	}
+	return "", nil, nil
+}
+
+// enumStrings extracts the string values from an enum payload: an array of
+// strings, a {"_0": value} object whose value is a string, an array of
+// strings or a nested enum case, or null. Nested cases yield their name.
+func enumStrings(payload json.RawMessage) []string {
+	if len(payload) == 0 || string(payload) == "null" {
+		return nil
+	}
+
+	var list []any
+	if err := json.Unmarshal(payload, &list); err != nil {
+		var labelled map[string]any
+		if err := json.Unmarshal(payload, &labelled); err != nil {
+			return nil
+		}
+		list = []any{labelled["_0"]}
+	}
+
+	var values []string
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			values = append(values, v)
+		case []any:
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					values = append(values, str)
+				}
+			}
+		case map[string]any:
+			if len(v) == 1 {
+				for kind := range v {
+					values = append(values, kind)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// SourceDirs returns the directories, relative to the package root, where
+// SwiftPM looks for the target's sources. An explicit path takes precedence
+// over the conventional locations.
+func (t Target) SourceDirs() []string {
+	if t.Path != "" {
+		return []string{t.Path}
+	}
+
+	switch t.Type {
+	case "binary":
+		// Remote binary targets have no local sources.
+		return nil
+	case "test":
+		return []string{"Tests/" + t.Name, "Sources/" + t.Name, "Source/" + t.Name, "src/" + t.Name, "srcs/" + t.Name}
+	case "plugin":
+		return []string{"Plugins/" + t.Name}
+	default:
+		return []string{"Sources/" + t.Name, "Source/" + t.Name, "src/" + t.Name, "srcs/" + t.Name}
+	}
 }
 
 // ParseManifest extracts package info from Package.swift using Swift CLI.
@@ -60,6 +567,11 @@ func ParseManifest(ctx context.Context, workDir string) (*PackageManifest, error
 		return nil, fmt.Errorf("failed to parse manifest: %w", err)
 	}
 
+	return decodeManifest(output)
+}
+
+// decodeManifest parses dump-package JSON output.
+func decodeManifest(output []byte) (*PackageManifest, error) {
 	var manifest PackageManifest
 	if err := json.Unmarshal(output, &manifest); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
@@ -75,24 +587,36 @@ func UpdateVersionConstant(path, constantName, version string) error {
 		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
+	newContent, err := replaceVersionConstant(content, constantName, version)
+	if err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+
+	if err := os.WriteFile(path, newContent, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// replaceVersionConstant sets the string value of a version constant.
+func replaceVersionConstant(content []byte, constantName, version string) ([]byte, error) {
 	// Pattern: let packageVersion = "1.0.0" or let packageVersion: String = "1.0.0"
 	pattern := regexp.MustCompile(
 		fmt.Sprintf(`(let\s+%s\s*(?::\s*String\s*)?=\s*")([^"]+)(")`, regexp.QuoteMeta(constantName)),
 	)
 
 	if !pattern.Match(content) {
-		return fmt.Errorf("version constant '%s' not found in %s", constantName, path)
-	}
-
-	newContent := pattern.ReplaceAll(content, []byte(fmt.Sprintf("${1}%s${3}", version)))
-
-	if err := os.WriteFile(path, newContent, 0644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", path, err)
+		return nil, fmt.Errorf("version constant '%s' not found", constantName)
 	}
 
-	return nil
+	return pattern.ReplaceAll(content, []byte(fmt.Sprintf("${1}%s${3}", version))), nil
 }
 
+// swiftToolsVersionPattern matches // swift-tools-version:5.7 or
+// // swift-tools-version: 5.7.
+var swiftToolsVersionPattern = regexp.MustCompile(`//\s*swift-tools-version:\s*(\d+\.\d+(?:\.\d+)?)`)
+
 // ExtractSwiftToolsVersion extracts the swift-tools-version from Package.swift.
 func ExtractSwiftToolsVersion(path string) (string, error) {
 	content, err := os.ReadFile(path)
@@ -100,9 +624,7 @@ func ExtractSwiftToolsVersion(path string) (string, error) {
 		return "", fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	// Pattern: // swift-tools-version:5.7 or // swift-tools-version: 5.7
-	pattern := regexp.MustCompile(`//\s*swift-tools-version:\s*(\d+\.\d+(?:\.\d+)?)`)
-	matches := pattern.FindSubmatch(content)
+	matches := swiftToolsVersionPattern.FindSubmatch(content)
 	if len(matches) < 2 {
 		return "", fmt.Errorf("swift-tools-version not found in %s", path)
 	}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Sources of the previous release's manifest.
+const (
+	ManifestSourceGit      = "git"
+	ManifestSourceRegistry = "registry"
+)
+
+// ManifestCompatibilityConfig controls the comparison of Package.swift with
+// the manifest of the previous release.
+type ManifestCompatibilityConfig struct {
+	Enabled bool `json:"enabled"`
+	// Source is where the previous manifest is read from: "git" (the
+	// previous release tag) or "registry".
+	Source string `json:"source"`
+	// Allow lists the products, platforms and dependency identities, or
+	// swift-tools-version, whose breaking changes are accepted without a
+	// major release.
+	Allow []string `json:"allow"`
+	// AllowPartial skips the comparison, instead of failing, when either
+	// manifest can only be read partially without the Swift toolchain.
+	AllowPartial bool `json:"allow_partial"`
+}
+
+// validate reports a configuration problem, or "".
+func (c ManifestCompatibilityConfig) validate() string {
+	if !c.Enabled {
+		return ""
+	}
+	if c.Source != ManifestSourceGit && c.Source != ManifestSourceRegistry {
+		return fmt.Sprintf("source must be %s or %s, not %q", ManifestSourceGit, ManifestSourceRegistry, c.Source)
+	}
+	return ""
+}
+
+// ManifestChange is one difference between the manifests of two releases.
+type ManifestChange struct {
+	// Subject is the product, platform or dependency identity that
+	// changed, or swift-tools-version.
+	Subject     string `json:"subject"`
+	Description string `json:"description"`
+	// Breaking is set when consumers of the previous release may fail to
+	// build or resolve against the new one.
+	Breaking bool `json:"breaking"`
+}
+
+// toolsVersionSubject is the subject of swift-tools-version changes.
+const toolsVersionSubject = "swift-tools-version"
+
+// DiffManifests compares the products, platforms, tools version and
+// dependency requirements of the previous and current manifests. Removed
+// products or library modules, raised or newly declared platform minimums,
+// a raised tools version, product kind changes and dependency requirements
+// that no longer overlap their previous range are breaking.
+func DiffManifests(previous, current *PackageManifest) []ManifestChange {
+	var changes []ManifestChange
+	add := func(subject string, breaking bool, format string, args ...any) {
+		changes = append(changes, ManifestChange{Subject: subject, Description: fmt.Sprintf(format, args...), Breaking: breaking})
+	}
+
+	if c := compareDottedVersions(previous.ToolsVersion.Version, current.ToolsVersion.Version); c != 0 {
+		add(toolsVersionSubject, c < 0, "swift-tools-version changed from %s to %s", previous.ToolsVersion, current.ToolsVersion)
+	}
+
+	for _, old := range previous.Products {
+		i := slices.IndexFunc(current.Products, func(p Product) bool { return p.Name == old.Name })
+		if i < 0 {
+			add(old.Name, true, "%s product %s was removed", old.Type.Kind, old.Name)
+			continue
+		}
+		product := current.Products[i]
+		if product.Type.Kind != old.Type.Kind {
+			add(old.Name, true, "product %s changed from %s to %s", old.Name, old.Type.Kind, product.Type.Kind)
+			continue
+		}
+		if product.Type.Linkage != old.Type.Linkage {
+			add(old.Name, false, "product %s linkage changed from %s to %s", old.Name, old.Type.Linkage, product.Type.Linkage)
+		}
+		if product.Type.Kind != ProductLibrary {
+			continue
+		}
+		for _, module := range old.Targets {
+			if !slices.Contains(product.Targets, module) {
+				add(old.Name, true, "library %s no longer includes module %s", old.Name, module)
+			}
+		}
+		for _, module := range product.Targets {
+			if !slices.Contains(old.Targets, module) {
+				add(old.Name, false, "library %s now includes module %s", old.Name, module)
+			}
+		}
+	}
+	for _, product := range current.Products {
+		if !slices.ContainsFunc(previous.Products, func(p Product) bool { return p.Name == product.Name }) {
+			add(product.Name, false, "%s product %s was added", product.Type.Kind, product.Name)
+		}
+	}
+
+	// An undeclared platform supports the oldest deployment target
+	for _, old := range previous.Platforms {
+		i := slices.IndexFunc(current.Platforms, func(p Platform) bool { return p.Name == old.Name })
+		if i < 0 {
+			add(old.Name, false, "platform %s minimum %s was removed", old.Name, old.Version)
+			continue
+		}
+		if c := compareDottedVersions(old.Version, current.Platforms[i].Version); c != 0 {
+			add(old.Name, c < 0, "platform %s minimum changed from %s to %s", old.Name, old.Version, current.Platforms[i].Version)
+		}
+	}
+	for _, platform := range current.Platforms {
+		if !slices.ContainsFunc(previous.Platforms, func(p Platform) bool { return p.Name == platform.Name }) {
+			add(platform.Name, true, "platform %s minimum set to %s", platform.Name, platform.Version)
+		}
+	}
+
+	for _, old := range previous.Dependencies {
+		name := dependencyIdentity(old)
+		i := slices.IndexFunc(current.Dependencies, func(d Dependency) bool { return dependencyIdentity(d) == name })
+		if i < 0 {
+			add(name, false, "dependency %s was removed", name)
+			continue
+		}
+		dep := current.Dependencies[i]
+		if old.Requirement == nil || dep.Requirement == nil {
+			if (old.Requirement == nil) != (dep.Requirement == nil) {
+				add(name, true, "dependency %s changed between a local path and a version requirement", name)
+			}
+			continue
+		}
+		if *old.Requirement != *dep.Requirement {
+			add(name, !requirementsOverlap(*old.Requirement, *dep.Requirement),
+				"dependency %s requirement changed from %s to %s", name, old.Requirement, dep.Requirement)
+		}
+	}
+	for _, dep := range current.Dependencies {
+		name := dependencyIdentity(dep)
+		if !slices.ContainsFunc(previous.Dependencies, func(d Dependency) bool { return dependencyIdentity(d) == name }) {
+			add(name, false, "dependency %s was added", name)
+		}
+	}
+	return changes
+}
+
+// dependencyIdentity returns the lowercase identity of a dependency.
+func dependencyIdentity(dep Dependency) string {
+	if dep.Identity != "" {
+		return strings.ToLower(dep.Identity)
+	}
+	return identityFromLocation(dep.Location)
+}
+
+// requirementsOverlap reports whether some version satisfies both
+// requirements. Branch and revision requirements only overlap themselves.
+func requirementsOverlap(a, b Requirement) bool {
+	switch {
+	case a.Kind == RequirementExact:
+		return pinSatisfies(ResolvedPin{Version: a.Value}, b)
+	case b.Kind == RequirementExact:
+		return pinSatisfies(ResolvedPin{Version: b.Value}, a)
+	case a.Kind == RequirementRange && b.Kind == RequirementRange:
+		return compareSemanticVersions(a.LowerBound, b.UpperBound) < 0 &&
+			compareSemanticVersions(b.LowerBound, a.UpperBound) < 0
+	default:
+		return a == b
+	}
+}
+
+// CheckManifestChanges returns the breaking changes whose subject is not in
+// cfg.Allow.
+func CheckManifestChanges(changes []ManifestChange, cfg ManifestCompatibilityConfig) []ManifestChange {
+	var rejected []ManifestChange
+	for _, change := range changes {
+		allowed := slices.ContainsFunc(cfg.Allow, func(a string) bool { return strings.EqualFold(a, change.Subject) })
+		if change.Breaking && !allowed {
+			rejected = append(rejected, change)
+		}
+	}
+	return rejected
+}
+
+// manifestAtTag returns the Package.swift of workDir as of a git tag.
+func manifestAtTag(ctx context.Context, workDir, tag string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "show", tag+":./Package.swift")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read Package.swift at %s: %w", tag, err)
+	}
+	return string(output), nil
+}
+
+// readManifest parses Package.swift in workDir with `swift package
+// dump-package` when the Swift toolchain is available, and statically
+// otherwise. The warnings describe anything a static read left out.
+func readManifest(ctx context.Context, workDir string) (*PackageManifest, []string, error) {
+	if _, err := exec.LookPath("swift"); err != nil {
+		return ParseManifestStatic(workDir)
+	}
+	manifest, err := ParseManifest(ctx, workDir)
+	return manifest, nil, err
+}
+
+// readManifestSource parses the Package.swift source of another revision
+// like readManifest, evaluating it in a temporary directory.
+func readManifestSource(ctx context.Context, source, workDir string) (*PackageManifest, []string, error) {
+	if _, err := exec.LookPath("swift"); err != nil {
+		return parseManifestSource(source, workDir)
+	}
+
+	dir, err := os.MkdirTemp("", "swift-pm-manifest-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "Package.swift"), []byte(source), 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to write Package.swift: %w", err)
+	}
+	manifest, err := ParseManifest(ctx, dir)
+	return manifest, nil, err
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const compatPreviousManifest = `// swift-tools-version:5.7
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    platforms: [.macOS(.v12), .iOS(.v15), .tvOS(.v15)],
+    products: [
+        .library(name: "Kit", targets: ["KitCore", "KitUI"]),
+        .library(name: "KitLegacy", targets: ["KitLegacy"]),
+        .executable(name: "kit", targets: ["KitCLI"]),
+    ],
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-argument-parser", from: "1.2.0"),
+        .package(url: "https://github.com/apple/swift-log", from: "1.4.0"),
+        .package(url: "https://github.com/jpsim/Yams", from: "4.0.0"),
+    ],
+    targets: []
+)
+`
+
+const compatCurrentManifest = `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    platforms: [.macOS(.v13), .iOS(.v14), .watchOS(.v8)],
+    products: [
+        .library(name: "Kit", type: .dynamic, targets: ["KitCore", "KitMacros"]),
+        .executable(name: "kit", targets: ["KitCLI"]),
+        .plugin(name: "KitPlugin", targets: ["KitPlugin"]),
+    ],
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-argument-parser", from: "1.3.0"),
+        .package(url: "https://github.com/jpsim/Yams", from: "5.0.0"),
+        .package(url: "https://github.com/apple/swift-syntax", from: "509.0.0"),
+    ],
+    targets: []
+)
+`
+
+func TestDiffManifests(t *testing.T) {
+	parse := func(source string) *PackageManifest {
+		t.Helper()
+		manifest, _, err := parseManifestSource(source, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return manifest
+	}
+
+	got := DiffManifests(parse(compatPreviousManifest), parse(compatCurrentManifest))
+	want := []ManifestChange{
+		{Subject: "swift-tools-version", Description: "swift-tools-version changed from 5.7.0 to 5.9.0", Breaking: true},
+		{Subject: "Kit", Description: "product Kit linkage changed from automatic to dynamic"},
+		{Subject: "Kit", Description: "library Kit no longer includes module KitUI", Breaking: true},
+		{Subject: "Kit", Description: "library Kit now includes module KitMacros"},
+		{Subject: "KitLegacy", Description: "library product KitLegacy was removed", Breaking: true},
+		{Subject: "KitPlugin", Description: "plugin product KitPlugin was added"},
+		{Subject: "macos", Description: "platform macos minimum changed from 12.0 to 13.0", Breaking: true},
+		{Subject: "ios", Description: "platform ios minimum changed from 15.0 to 14.0"},
+		{Subject: "tvos", Description: "platform tvos minimum 15.0 was removed"},
+		{Subject: "watchos", Description: "platform watchos minimum set to 8.0", Breaking: true},
+		{Subject: "swift-argument-parser", Description: "dependency swift-argument-parser requirement changed from 1.2.0..<2.0.0 to 1.3.0..<2.0.0"},
+		{Subject: "swift-log", Description: "dependency swift-log was removed"},
+		{Subject: "yams", Description: "dependency yams requirement changed from 4.0.0..<5.0.0 to 5.0.0..<6.0.0", Breaking: true},
+		{Subject: "swift-syntax", Description: "dependency swift-syntax was added"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffManifests() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := DiffManifests(parse(compatCurrentManifest), parse(compatCurrentManifest)); got != nil {
+		t.Errorf("DiffManifests() of the same manifest = %+v", got)
+	}
+}
+
+func TestRequirementsOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Requirement
+		want bool
+	}{
+		{"narrowed range", Requirement{Kind: RequirementRange, LowerBound: "1.0.0", UpperBound: "2.0.0"}, Requirement{Kind: RequirementRange, LowerBound: "1.5.0", UpperBound: "2.0.0"}, true},
+		{"next major", Requirement{Kind: RequirementRange, LowerBound: "1.0.0", UpperBound: "2.0.0"}, Requirement{Kind: RequirementRange, LowerBound: "2.0.0", UpperBound: "3.0.0"}, false},
+		{"exact in range", Requirement{Kind: RequirementExact, Value: "1.4.2"}, Requirement{Kind: RequirementRange, LowerBound: "1.0.0", UpperBound: "2.0.0"}, true},
+		{"range around exact", Requirement{Kind: RequirementRange, LowerBound: "1.0.0", UpperBound: "1.4.0"}, Requirement{Kind: RequirementExact, Value: "1.4.2"}, false},
+		{"different branches", Requirement{Kind: RequirementBranch, Value: "main"}, Requirement{Kind: RequirementBranch, Value: "develop"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requirementsOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("requirementsOverlap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckManifestChanges(t *testing.T) {
+	changes := []ManifestChange{
+		{Subject: "swift-tools-version", Description: "swift-tools-version changed from 5.7.0 to 5.9.0", Breaking: true},
+		{Subject: "macos", Description: "platform macos minimum changed from 12.0 to 13.0", Breaking: true},
+		{Subject: "KitPlugin", Description: "plugin product KitPlugin was added"},
+	}
+
+	got := CheckManifestChanges(changes, ManifestCompatibilityConfig{Enabled: true, Allow: []string{"macOS"}})
+	if !reflect.DeepEqual(got, changes[:1]) {
+		t.Errorf("CheckManifestChanges() = %+v, want %+v", got, changes[:1])
+	}
+}
+
+func TestManifestCompatibilityConfig_Validate(t *testing.T) {
+	if problem := (ManifestCompatibilityConfig{Enabled: true, Source: ManifestSourceRegistry}).validate(); problem != "" {
+		t.Errorf("validate() = %q", problem)
+	}
+	if problem := (ManifestCompatibilityConfig{Enabled: true, Source: "cache"}).validate(); !strings.Contains(problem, `not "cache"`) {
+		t.Errorf("validate() = %q", problem)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Lint severities. A rule set to SeverityOff is not run.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// LintConfig controls the Package.swift lint rules run before publishing.
+type LintConfig struct {
+	Enabled bool `json:"enabled"`
+	// Rules overrides the severity of rules by name.
+	Rules map[string]string `json:"rules"`
+	// MinToolsVersion is the lowest swift-tools-version accepted by the
+	// min_tools_version rule, which is skipped when it is empty.
+	MinToolsVersion string `json:"min_tools_version"`
+}
+
+// LintFinding is one problem reported by a lint rule.
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// lintRule checks a manifest and returns a message per problem.
+type lintRule struct {
+	name string
+	// severity is used unless the configuration overrides it.
+	severity string
+	check    func(manifest *PackageManifest, cfg LintConfig) []string
+}
+
+// lintRules are the available rules, in the order they run.
+var lintRules = []lintRule{
+	{"library_targets", SeverityError, lintLibraryTargets},
+	{"unused_targets", SeverityWarning, lintUnusedTargets},
+	{"test_dependencies", SeverityWarning, lintTestDependencies},
+	{"unsafe_flags", SeverityError, lintUnsafeFlags},
+	{"min_tools_version", SeverityError, lintMinToolsVersion},
+	{"platforms", SeverityWarning, lintPlatforms},
+	{"bounded_dependencies", SeverityWarning, lintBoundedDependencies},
+}
+
+// dottedVersion matches a version such as 5.9 or 5.10.1.
+var dottedVersion = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// validate reports a configuration problem, or "".
+func (c LintConfig) validate() string {
+	if !c.Enabled {
+		return ""
+	}
+	names := make([]string, 0, len(c.Rules))
+	for name := range c.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.ContainsFunc(lintRules, func(r lintRule) bool { return r.name == name }) {
+			return fmt.Sprintf("has unknown rule %q", name)
+		}
+		switch c.Rules[name] {
+		case SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return fmt.Sprintf("rule %s has invalid severity %q (use %s, %s or %s)",
+				name, c.Rules[name], SeverityError, SeverityWarning, SeverityOff)
+		}
+	}
+	if c.MinToolsVersion != "" && !dottedVersion.MatchString(c.MinToolsVersion) {
+		return fmt.Sprintf("min_tools_version %q is not a version", c.MinToolsVersion)
+	}
+	return ""
+}
+
+// LintManifest runs the enabled lint rules over a manifest.
+func LintManifest(manifest *PackageManifest, cfg LintConfig) []LintFinding {
+	var findings []LintFinding
+	for _, rule := range lintRules {
+		severity := rule.severity
+		if s, ok := cfg.Rules[rule.name]; ok {
+			severity = s
+		}
+		if severity == SeverityOff {
+			continue
+		}
+		for _, message := range rule.check(manifest, cfg) {
+			findings = append(findings, LintFinding{Rule: rule.name, Severity: severity, Message: message})
+		}
+	}
+	return findings
+}
+
+// lintLibraryTargets reports library products without targets.
+func lintLibraryTargets(manifest *PackageManifest, _ LintConfig) []string {
+	var problems []string
+	for _, product := range manifest.Products {
+		if product.Type.Kind == ProductLibrary && len(product.Targets) == 0 {
+			problems = append(problems, fmt.Sprintf("library %s has no targets", product.Name))
+		}
+	}
+	return problems
+}
+
+// lintUnusedTargets reports targets no product, executable, plugin or test
+// target uses, directly or through other targets.
+func lintUnusedTargets(manifest *PackageManifest, _ LintConfig) []string {
+	used := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		i := slices.IndexFunc(manifest.Targets, func(t Target) bool { return t.Name == name })
+		if i < 0 || used[name] {
+			return
+		}
+		used[name] = true
+		for _, dep := range manifest.Targets[i].Dependencies {
+			if dep.Kind != TargetDependencyProduct {
+				visit(dep.Name)
+			}
+		}
+	}
+
+	for _, product := range manifest.Products {
+		for _, name := range product.Targets {
+			visit(name)
+		}
+	}
+	// Executables get implicit products, and plugins may be used through
+	// plugins: without a product
+	for _, target := range manifest.Targets {
+		if target.Type == "executable" || target.Type == "plugin" || target.Type == "test" {
+			visit(target.Name)
+		}
+	}
+
+	var problems []string
+	for _, target := range manifest.Targets {
+		if !used[target.Name] {
+			problems = append(problems, fmt.Sprintf("target %s is not used by any product or target", target.Name))
+		}
+	}
+	return problems
+}
+
+// lintTestDependencies reports test targets that do not depend on the
+// target they are named after (KitTests on Kit) or, failing that, on any
+// target of the package.
+func lintTestDependencies(manifest *PackageManifest, _ LintConfig) []string {
+	isTarget := func(name string) bool {
+		return slices.ContainsFunc(manifest.Targets, func(t Target) bool { return t.Name == name && t.Type != "test" })
+	}
+
+	var problems []string
+	for _, target := range manifest.Targets {
+		if target.Type != "test" {
+			continue
+		}
+		dependsOn := func(name string) bool {
+			return slices.ContainsFunc(target.Dependencies, func(d TargetDependency) bool {
+				return d.Kind != TargetDependencyProduct && d.Name == name
+			})
+		}
+		if tested := strings.TrimSuffix(target.Name, "Tests"); tested != target.Name && isTarget(tested) {
+			if !dependsOn(tested) {
+				problems = append(problems, fmt.Sprintf("test target %s does not depend on %s", target.Name, tested))
+			}
+			continue
+		}
+		if !slices.ContainsFunc(target.Dependencies, func(d TargetDependency) bool {
+			return d.Kind != TargetDependencyProduct && isTarget(d.Name)
+		}) {
+			problems = append(problems, fmt.Sprintf("test target %s does not depend on any target of the package", target.Name))
+		}
+	}
+	return problems
+}
+
+// lintUnsafeFlags reports unsafeFlags settings, which make the package
+// unusable as a versioned dependency.
+func lintUnsafeFlags(manifest *PackageManifest, _ LintConfig) []string {
+	var problems []string
+	for _, target := range manifest.Targets {
+		for _, setting := range target.Settings {
+			if setting.Kind == "unsafeFlags" {
+				problems = append(problems, fmt.Sprintf("target %s uses unsafeFlags in %s settings (%s)",
+					target.Name, setting.Tool, strings.Join(setting.Values, " ")))
+			}
+		}
+	}
+	return problems
+}
+
+// lintMinToolsVersion reports a swift-tools-version below the configured
+// minimum.
+func lintMinToolsVersion(manifest *PackageManifest, cfg LintConfig) []string {
+	if cfg.MinToolsVersion == "" || compareDottedVersions(manifest.ToolsVersion.Version, cfg.MinToolsVersion) >= 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("swift-tools-version %s is below %s", manifest.ToolsVersion, cfg.MinToolsVersion)}
+}
+
+// lintPlatforms reports a manifest that declares no platforms.
+func lintPlatforms(manifest *PackageManifest, _ LintConfig) []string {
+	if len(manifest.Platforms) > 0 {
+		return nil
+	}
+	return []string{"no platforms are declared"}
+}
+
+// lintBoundedDependencies reports version ranges reaching past the next
+// major version of their lower bound, which admit breaking releases.
+func lintBoundedDependencies(manifest *PackageManifest, _ LintConfig) []string {
+	var problems []string
+	for _, dep := range manifest.Dependencies {
+		req := dep.Requirement
+		if req == nil || req.Kind != RequirementRange {
+			continue
+		}
+		major, _, _, ok := versionComponents(req.LowerBound)
+		if !ok || req.UpperBound == "" || compareSemanticVersions(req.UpperBound, fmt.Sprintf("%d.0.0", major+1)) > 0 {
+			problems = append(problems, fmt.Sprintf("dependency %s range %s is not bounded by the next major version",
+				dependencyIdentity(dep), req))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintManifest(t *testing.T) {
+	tests := []struct {
+		file string
+		cfg  LintConfig
+		want []LintFinding
+	}{
+		{
+			file: "literal.swift",
+			cfg:  LintConfig{Enabled: true, MinToolsVersion: "5.9"},
+			want: []LintFinding{
+				{Rule: "unsafe_flags", Severity: SeverityError, Message: "target Networking uses unsafeFlags in swift settings (-Xfrontend -warn-long-function-bodies=200)"},
+				{Rule: "min_tools_version", Severity: SeverityError, Message: "swift-tools-version 5.7.0 is below 5.9"},
+			},
+		},
+		{
+			file: "literal.swift",
+			cfg:  LintConfig{Enabled: true, Rules: map[string]string{"unsafe_flags": SeverityWarning}},
+			want: []LintFinding{
+				{Rule: "unsafe_flags", Severity: SeverityWarning, Message: "target Networking uses unsafeFlags in swift settings (-Xfrontend -warn-long-function-bodies=200)"},
+			},
+		},
+		{
+			file: "modern.swift",
+			cfg:  LintConfig{Enabled: true},
+			want: []LintFinding{
+				{Rule: "unused_targets", Severity: SeverityWarning, Message: "target CZlib is not used by any product or target"},
+				{Rule: "unused_targets", Severity: SeverityWarning, Message: "target ToolkitC is not used by any product or target"},
+			},
+		},
+		{
+			file: "modern.swift",
+			cfg:  LintConfig{Enabled: true, Rules: map[string]string{"unused_targets": SeverityOff}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			dir := filepath.Join("testdata", "package-swift")
+			source, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest, _, err := parseManifestSource(string(source), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := LintManifest(manifest, tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintManifest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	library := func(name string, targets ...string) Product {
+		return Product{Name: name, Type: ProductType{Kind: ProductLibrary}, Targets: targets}
+	}
+	uses := func(names ...string) []TargetDependency {
+		deps := make([]TargetDependency, len(names))
+		for i, name := range names {
+			deps[i] = TargetDependency{Kind: TargetDependencyByName, Name: name}
+		}
+		return deps
+	}
+	rangeDep := func(identity, lower, upper string) Dependency {
+		return Dependency{Kind: DependencySourceControl, Identity: identity,
+			Requirement: &Requirement{Kind: RequirementRange, LowerBound: lower, UpperBound: upper}}
+	}
+
+	tests := []struct {
+		name     string
+		manifest PackageManifest
+		rule     string
+		want     []string
+	}{
+		{
+			name:     "library without targets",
+			manifest: PackageManifest{Products: []Product{library("Kit"), library("KitCore", "KitCore")}},
+			rule:     "library_targets",
+			want:     []string{"library Kit has no targets"},
+		},
+		{
+			name: "used through another target",
+			manifest: PackageManifest{
+				Products: []Product{library("Kit", "Kit")},
+				Targets: []Target{
+					{Name: "Kit", Type: "regular", Dependencies: uses("KitCore")},
+					{Name: "KitCore", Type: "regular"},
+					{Name: "Scratch", Type: "regular"},
+					{Name: "Tool", Type: "executable"},
+				},
+			},
+			rule: "unused_targets",
+			want: []string{"target Scratch is not used by any product or target"},
+		},
+		{
+			name: "test dependencies",
+			manifest: PackageManifest{
+				Targets: []Target{
+					{Name: "Kit", Type: "regular"},
+					{Name: "KitCore", Type: "regular"},
+					{Name: "KitTests", Type: "test", Dependencies: uses("KitCore")},
+					{Name: "KitCoreTests", Type: "test", Dependencies: uses("KitCore")},
+					{Name: "IntegrationTests", Type: "test", Dependencies: uses("Kit")},
+					{Name: "SmokeTests", Type: "test"},
+				},
+			},
+			rule: "test_dependencies",
+			want: []string{
+				"test target KitTests does not depend on Kit",
+				"test target SmokeTests does not depend on any target of the package",
+			},
+		},
+		{
+			name:     "platforms",
+			manifest: PackageManifest{},
+			rule:     "platforms",
+			want:     []string{"no platforms are declared"},
+		},
+		{
+			name: "bounded dependencies",
+			manifest: PackageManifest{Dependencies: []Dependency{
+				rangeDep("swift-log", "1.4.0", "2.0.0"),
+				rangeDep("swift-nio", "2.0.0", "99.0.0"),
+				rangeDep("swift-syntax", "509.0.0", "601.0.0"),
+				rangeDep("swift-format", "0.5.0", "1.0.0"),
+			}},
+			rule: "bounded_dependencies",
+			want: []string{
+				"dependency swift-nio range 2.0.0..<99.0.0 is not bounded by the next major version",
+				"dependency swift-syntax range 509.0.0..<601.0.0 is not bounded by the next major version",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Turn every other rule off
+			cfg := LintConfig{Enabled: true, Rules: map[string]string{}}
+			for _, rule := range lintRules {
+				if rule.name != tt.rule {
+					cfg.Rules[rule.name] = SeverityOff
+				}
+			}
+			var got []string
+			for _, finding := range LintManifest(&tt.manifest, cfg) {
+				got = append(got, finding.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %q, want %q", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  LintConfig
+		want string
+	}{
+		{"defaults", LintConfig{Enabled: true}, ""},
+		{"overrides", LintConfig{Enabled: true, Rules: map[string]string{"platforms": SeverityError, "unused_targets": SeverityOff}, MinToolsVersion: "5.9"}, ""},
+		{"unknown rule", LintConfig{Enabled: true, Rules: map[string]string{"no_todos": SeverityError}}, `unknown rule "no_todos"`},
+		{"bad severity", LintConfig{Enabled: true, Rules: map[string]string{"platforms": "fatal"}}, `invalid severity "fatal"`},
+		{"bad tools version", LintConfig{Enabled: true, MinToolsVersion: "latest"}, "is not a version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.validate()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
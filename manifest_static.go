@@ -0,0 +1,1195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LoadManifest parses Package.swift in workDir with `swift package
+// dump-package` when the Swift toolchain is available. Without it, the
+// manifest is read by ParseManifestStatic and the returned warnings describe
+// anything that could not be analysed.
+func LoadManifest(ctx context.Context, workDir string) (*PackageManifest, []string, error) {
+	if _, err := exec.LookPath("swift"); err == nil {
+		manifest, err := ParseManifest(ctx, workDir)
+		return manifest, nil, err
+	}
+
+	manifest, warnings, err := ParseManifestStatic(workDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append([]string{"Swift toolchain not found; Package.swift was read without evaluating it"}, warnings...)
+	return manifest, warnings, nil
+}
+
+// ParseManifestStatic reads Package.swift in workDir without running Swift.
+// It understands the common literal forms of Package(name:platforms:
+// products:dependencies:targets:), including top-level let constants, and
+// reports a warning for every part of the manifest that is computed at
+// runtime and therefore left out of the result.
+func ParseManifestStatic(workDir string) (*PackageManifest, []string, error) {
+	manifestPath := filepath.Join(workDir, "Package.swift")
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", manifestPath, err)
+	}
+
+	manifest, warnings, err := parseManifestSource(string(content), workDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", manifestPath, err)
+	}
+	return manifest, warnings, nil
+}
+
+// parseManifestSource statically evaluates manifest source. Relative
+// file system dependency paths are resolved against workDir.
+func parseManifestSource(source, workDir string) (*PackageManifest, []string, error) {
+	tokens, err := tokenizeSwift(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &manifestReader{
+		parser:   swiftParser{tokens: tokens},
+		bindings: make(map[string]*swiftExpr),
+		workDir:  workDir,
+	}
+	r.readTopLevel()
+
+	pkg := r.bindings["package"]
+	if pkg == nil || pkg.kind != exprCall || pkg.name != "Package" {
+		return nil, nil, fmt.Errorf("no `let package = Package(...)` declaration found")
+	}
+
+	manifest := r.readPackage(pkg)
+	if manifest.Name == "" {
+		return nil, nil, fmt.Errorf("package name is not a string literal")
+	}
+
+	if version := swiftToolsVersionPattern.FindStringSubmatch(source); version != nil {
+		manifest.ToolsVersion = ToolsVersion{Version: normalizeVersion(version[1])}
+	} else {
+		r.warnf(0, "swift-tools-version comment not found")
+	}
+
+	return manifest, r.warningMessages(), nil
+}
+
+// Swift tokens.
+type swiftTokenKind int
+
+const (
+	tokEOF swiftTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type swiftToken struct {
+	kind swiftTokenKind
+	text string
+	// raw is the source text of string literals.
+	raw  string
+	line int
+	// interpolated is set for string literals containing \( ... ).
+	interpolated bool
+}
+
+// swiftPunctuation lists multi-character operators, longest first.
+var swiftPunctuation = []string{"..<", "...", "->", "+=", "-=", "==", "!=", "&&", "||", "??"}
+
+// tokenizeSwift splits Swift source into tokens, dropping comments.
+func tokenizeSwift(src string) ([]swiftToken, error) {
+	var tokens []swiftToken
+	line := 1
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			// Block comments nest in Swift
+			depth := 0
+			for i < len(src) {
+				if strings.HasPrefix(src[i:], "/*") {
+					depth++
+					i += 2
+				} else if strings.HasPrefix(src[i:], "*/") {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					if src[i] == '\n' {
+						line++
+					}
+					i++
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+		case c == '"' || (c == '#' && strings.HasPrefix(strings.TrimLeft(src[i:], "#"), `"`)):
+			tok, next, err := scanSwiftString(src, i, line)
+			if err != nil {
+				return nil, err
+			}
+			line += strings.Count(src[i:next], "\n")
+			tokens = append(tokens, tok)
+			i = next
+		case c == '_' || c == '$' || c == '`' || unicode.IsLetter(rune(c)):
+			start := i
+			if c == '`' {
+				end := strings.IndexByte(src[i+1:], '`')
+				if end < 0 {
+					return nil, fmt.Errorf("line %d: unterminated identifier", line)
+				}
+				tokens = append(tokens, swiftToken{kind: tokIdent, text: src[i+1 : i+1+end], line: line})
+				i += end + 2
+				continue
+			}
+			for i < len(src) && (src[i] == '_' || src[i] == '$' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, swiftToken{kind: tokIdent, text: src[start:i], line: line})
+		case unicode.IsDigit(rune(c)):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '_' ||
+				(src[i] == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1])))) {
+				i++
+			}
+			tokens = append(tokens, swiftToken{kind: tokNumber, text: src[start:i], line: line})
+		default:
+			text := string(c)
+			for _, p := range swiftPunctuation {
+				if strings.HasPrefix(src[i:], p) {
+					text = p
+					break
+				}
+			}
+			tokens = append(tokens, swiftToken{kind: tokPunct, text: text, line: line})
+			i += len(text)
+		}
+	}
+	return append(tokens, swiftToken{kind: tokEOF, line: line}), nil
+}
+
+// scanSwiftString reads a string literal starting at i: "...", """...""" or
+// a raw #"..."# string. It returns the token and the index after it.
+func scanSwiftString(src string, i, line int) (swiftToken, int, error) {
+	start := i
+	hashes := 0
+	for i < len(src) && src[i] == '#' {
+		hashes++
+		i++
+	}
+	delimiter := `"`
+	if strings.HasPrefix(src[i:], `"""`) {
+		delimiter = `"""`
+	}
+	closing := delimiter + strings.Repeat("#", hashes)
+	escape := `\` + strings.Repeat("#", hashes)
+
+	tok := swiftToken{kind: tokString, line: line}
+	var value strings.Builder
+	j := i + len(delimiter)
+	for {
+		if j >= len(src) || (delimiter == `"` && src[j] == '\n') {
+			return tok, 0, fmt.Errorf("line %d: unterminated string literal", line)
+		}
+		if strings.HasPrefix(src[j:], closing) {
+			j += len(closing)
+			break
+		}
+		if strings.HasPrefix(src[j:], escape) {
+			j += len(escape)
+			if j >= len(src) {
+				continue
+			}
+			switch src[j] {
+			case '(':
+				tok.interpolated = true
+				depth := 0
+				for ; j < len(src); j++ {
+					if src[j] == '(' {
+						depth++
+					} else if src[j] == ')' {
+						depth--
+						if depth == 0 {
+							break
+						}
+					}
+				}
+				j++
+			case 'n':
+				value.WriteByte('\n')
+				j++
+			case 't':
+				value.WriteByte('\t')
+				j++
+			case '0':
+				value.WriteByte(0)
+				j++
+			case 'u':
+				end := strings.IndexByte(src[j:], '}')
+				if end < 0 {
+					return tok, 0, fmt.Errorf("line %d: invalid unicode escape", line)
+				}
+				code, err := strconv.ParseUint(strings.Trim(src[j+1:j+end], "{"), 16, 32)
+				if err != nil {
+					return tok, 0, fmt.Errorf("line %d: invalid unicode escape", line)
+				}
+				value.WriteRune(rune(code))
+				j += end + 1
+			default:
+				value.WriteByte(src[j])
+				j++
+			}
+			continue
+		}
+		value.WriteByte(src[j])
+		j++
+	}
+
+	text := value.String()
+	if delimiter == `"""` {
+		text = trimMultilineString(text)
+	}
+	tok.text = text
+	tok.raw = src[start:j]
+	return tok, j, nil
+}
+
+// trimMultilineString removes the line breaks after the opening and before
+// the closing delimiter and the closing delimiter's indentation.
+func trimMultilineString(s string) string {
+	s = strings.TrimPrefix(s, "\n")
+	last := strings.LastIndexByte(s, '\n')
+	if last < 0 {
+		return s
+	}
+	indent := s[last+1:]
+	lines := strings.Split(s[:last], "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Swift expression kinds recognised by the static reader.
+type swiftExprKind int
+
+const (
+	exprDynamic swiftExprKind = iota
+	exprString
+	exprNumber
+	exprBool
+	exprNil
+	exprArray
+	exprCall
+	exprMember
+	exprIdent
+	exprRange
+)
+
+// swiftExpr is a literal-only subset of Swift expressions.
+type swiftExpr struct {
+	kind swiftExprKind
+	// name is the string value, member or function name, or identifier.
+	name string
+	// args holds call arguments.
+	args []swiftArg
+	// elems holds array elements, or the bounds of a range.
+	elems []*swiftExpr
+	// closed is set for ... ranges.
+	closed bool
+	line   int
+	// source is the expression text, used in warnings.
+	source string
+}
+
+type swiftArg struct {
+	label string
+	value *swiftExpr
+}
+
+// arg returns the argument with the given label, or nil.
+func (e *swiftExpr) arg(label string) *swiftExpr {
+	for _, a := range e.args {
+		if a.label == label {
+			return a.value
+		}
+	}
+	return nil
+}
+
+// positional returns the n-th unlabelled argument, or nil.
+func (e *swiftExpr) positional(n int) *swiftExpr {
+	for _, a := range e.args {
+		if a.label == "" {
+			if n == 0 {
+				return a.value
+			}
+			n--
+		}
+	}
+	return nil
+}
+
+// swiftParser parses expressions from a token stream.
+type swiftParser struct {
+	tokens []swiftToken
+	pos    int
+}
+
+func (p *swiftParser) peek() swiftToken {
+	return p.tokens[p.pos]
+}
+
+func (p *swiftParser) peekAt(offset int) swiftToken {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *swiftParser) next() swiftToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *swiftParser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+// sourceText renders the tokens from start up to the current position,
+// shortened for use in warnings.
+func (p *swiftParser) sourceText(start int) string {
+	var b strings.Builder
+	for i, tok := range p.tokens[start:p.pos] {
+		text := tok.text
+		if tok.kind == tokString {
+			text = tok.raw
+		}
+		spaced := tok.kind == tokPunct && strings.Contains("+-*/=?<>&|", text[:1])
+		if i > 0 && (spaced || (tok.kind != tokPunct && b.Len() > 0 && !strings.ContainsAny(b.String()[b.Len()-1:], ".([ "))) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(text)
+		if spaced || text == "," || text == ":" {
+			b.WriteByte(' ')
+		}
+	}
+	text := b.String()
+	if len(text) > 60 {
+		text = text[:57] + "..."
+	}
+	return text
+}
+
+// atStatementEnd reports whether the current token starts a new statement:
+// it begins a new line and the previous line did not end in an operator.
+func (p *swiftParser) atStatementEnd() bool {
+	if p.pos == 0 {
+		return false
+	}
+	tok, prev := p.peek(), p.tokens[p.pos-1]
+	if tok.kind == tokEOF {
+		return true
+	}
+	if tok.line == prev.line || (tok.kind == tokPunct && tok.text != "#" && tok.text != "@") {
+		return false
+	}
+	return prev.kind != tokPunct || strings.Contains(")]}", prev.text)
+}
+
+// skipBalanced skips tokens until an unbalanced closing bracket, a comma at
+// depth zero or the end of the statement, leaving that token unconsumed.
+func (p *swiftParser) skipBalanced() {
+	depth := 0
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || (depth == 0 && p.atStatementEnd()) {
+			return
+		}
+		if tok.kind == tokPunct {
+			switch tok.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth == 0 {
+					return
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					return
+				}
+			}
+		}
+		p.next()
+	}
+}
+
+// parseExpr parses an expression. Anything outside the supported literal
+// subset is consumed and returned as exprDynamic.
+func (p *swiftParser) parseExpr() *swiftExpr {
+	start := p.pos
+	line := p.peek().line
+
+	expr := p.parsePostfix()
+	if expr.kind != exprDynamic && (p.isPunct("..<") || p.isPunct("...")) {
+		closed := p.next().text == "..."
+		upper := p.parsePostfix()
+		expr = &swiftExpr{kind: exprRange, elems: []*swiftExpr{expr, upper}, closed: closed, line: line}
+	}
+
+	// Any other operator makes the value computed
+	tok := p.peek()
+	if expr.kind == exprDynamic || !(p.atStatementEnd() || (tok.kind == tokPunct && strings.Contains(",)]}", tok.text))) {
+		p.skipBalanced()
+		expr = &swiftExpr{kind: exprDynamic, line: line}
+	}
+	expr.source = p.sourceText(start)
+	return expr
+}
+
+// parsePostfix parses a primary expression with member accesses and calls.
+func (p *swiftParser) parsePostfix() *swiftExpr {
+	tok := p.peek()
+	line := tok.line
+
+	var expr *swiftExpr
+	switch {
+	case tok.kind == tokString:
+		p.next()
+		if tok.interpolated {
+			return &swiftExpr{kind: exprDynamic, line: line}
+		}
+		expr = &swiftExpr{kind: exprString, name: tok.text, line: line}
+	case tok.kind == tokNumber:
+		p.next()
+		expr = &swiftExpr{kind: exprNumber, name: tok.text, line: line}
+	case tok.kind == tokIdent && (tok.text == "true" || tok.text == "false"):
+		p.next()
+		expr = &swiftExpr{kind: exprBool, name: tok.text, line: line}
+	case tok.kind == tokIdent && tok.text == "nil":
+		p.next()
+		expr = &swiftExpr{kind: exprNil, line: line}
+	case tok.kind == tokPunct && tok.text == "[":
+		expr = p.parseArray()
+	case tok.kind == tokPunct && tok.text == "(":
+		p.next()
+		expr = p.parseExpr()
+		if !p.isPunct(")") {
+			return &swiftExpr{kind: exprDynamic, line: line}
+		}
+		p.next()
+	case tok.kind == tokPunct && tok.text == "." && p.peekAt(1).kind == tokIdent:
+		p.next()
+		expr = &swiftExpr{kind: exprMember, name: p.next().text, line: line}
+	case tok.kind == tokIdent:
+		// Qualified names such as Target.Dependency.product resolve to
+		// their last component; a bare name is a reference
+		p.next()
+		expr = &swiftExpr{kind: exprIdent, name: tok.text, line: line}
+		for p.isPunct(".") && p.peekAt(1).kind == tokIdent && startsUpper(expr.name) {
+			p.next()
+			expr = &swiftExpr{kind: exprMember, name: p.next().text, line: line}
+		}
+	default:
+		return &swiftExpr{kind: exprDynamic, line: line}
+	}
+
+	for {
+		switch {
+		case p.isPunct("(") && (expr.kind == exprMember || expr.kind == exprIdent):
+			p.next()
+			call := &swiftExpr{kind: exprCall, name: expr.name, line: line}
+			if !p.parseArgs(call) {
+				return &swiftExpr{kind: exprDynamic, line: line}
+			}
+			expr = call
+		case p.isPunct(".") || p.isPunct("(") || p.isPunct("{") || p.isPunct("!") || p.isPunct("?"):
+			// Method calls, closures and optional chaining are runtime values
+			return &swiftExpr{kind: exprDynamic, line: line}
+		default:
+			return expr
+		}
+	}
+}
+
+// parseArray parses an array literal. Dictionary literals are dynamic.
+func (p *swiftParser) parseArray() *swiftExpr {
+	line := p.next().line
+	expr := &swiftExpr{kind: exprArray, line: line}
+	for !p.isPunct("]") {
+		if p.peek().kind == tokEOF {
+			return &swiftExpr{kind: exprDynamic, line: line}
+		}
+		if p.isPunct(":") {
+			p.skipBalanced()
+			p.next()
+			return &swiftExpr{kind: exprDynamic, line: line}
+		}
+		elem := p.parseExpr()
+		if p.isPunct(":") {
+			p.skipBalanced()
+			if p.isPunct("]") {
+				p.next()
+			}
+			return &swiftExpr{kind: exprDynamic, line: line}
+		}
+		expr.elems = append(expr.elems, elem)
+		if p.isPunct(",") {
+			p.next()
+		} else if !p.isPunct("]") {
+			p.skipBalanced()
+		}
+	}
+	p.next()
+	return expr
+}
+
+// parseArgs parses a call argument list after the opening parenthesis.
+// Trailing closures are not supported and make the call dynamic.
+func (p *swiftParser) parseArgs(call *swiftExpr) bool {
+	for !p.isPunct(")") {
+		if p.peek().kind == tokEOF {
+			return false
+		}
+		var arg swiftArg
+		if tok := p.peek(); tok.kind == tokIdent && p.peekAt(1).kind == tokPunct && p.peekAt(1).text == ":" {
+			arg.label = tok.text
+			p.next()
+			p.next()
+		}
+		arg.value = p.parseExpr()
+		call.args = append(call.args, arg)
+		if p.isPunct(",") {
+			p.next()
+		} else if !p.isPunct(")") {
+			return false
+		}
+	}
+	p.next()
+	return !p.isPunct("{")
+}
+
+// startsUpper reports whether s starts with an upper case letter, as type
+// names do.
+func startsUpper(s string) bool {
+	return s != "" && unicode.IsUpper(rune(s[0]))
+}
+
+// manifestReader evaluates the top level of a manifest.
+type manifestReader struct {
+	parser   swiftParser
+	bindings map[string]*swiftExpr
+	workDir  string
+	warnings []manifestWarning
+}
+
+// manifestWarning is a part of the manifest that could not be read.
+type manifestWarning struct {
+	line    int
+	message string
+}
+
+func (r *manifestReader) warnf(line int, format string, args ...any) {
+	r.warnings = append(r.warnings, manifestWarning{line: line, message: fmt.Sprintf(format, args...)})
+}
+
+// warningMessages returns the distinct warnings ordered by line.
+func (r *manifestReader) warningMessages() []string {
+	slices.SortStableFunc(r.warnings, func(a, b manifestWarning) int { return a.line - b.line })
+	var messages []string
+	for _, w := range r.warnings {
+		msg := "Package.swift: " + w.message
+		if w.line > 0 {
+			msg = fmt.Sprintf("Package.swift:%d: %s", w.line, w.message)
+		}
+		if !slices.Contains(messages, msg) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// readTopLevel records top-level let and var bindings and warns about
+// constructs that make the manifest depend on runtime evaluation.
+func (r *manifestReader) readTopLevel() {
+	p := &r.parser
+	for _, tok := range p.tokens {
+		if tok.kind == tokIdent && (tok.text == "ProcessInfo" || tok.text == "Context") {
+			r.warnf(tok.line, "manifest reads %s at runtime; the static reading may differ", tok.text)
+		}
+	}
+
+	depth := 0
+	for p.peek().kind != tokEOF {
+		tok := p.peek()
+
+		if tok.kind == tokPunct {
+			switch tok.text {
+			case "{", "(", "[":
+				depth++
+			case "}", ")", "]":
+				depth--
+			case "#":
+				if next := p.peekAt(1); next.kind == tokIdent && next.text == "if" && depth == 0 {
+					r.warnf(tok.line, "#if conditional compilation is not evaluated; both branches were read")
+				}
+			}
+			p.next()
+			continue
+		}
+
+		if depth == 0 && tok.kind == tokIdent {
+			switch {
+			case (tok.text == "let" || tok.text == "var") && p.peekAt(1).kind == tokIdent:
+				p.next()
+				name := p.next().text
+				// Skip a type annotation
+				for !p.isPunct("=") && p.peek().kind != tokEOF && p.peek().line == tok.line {
+					if p.isPunct("[") || p.isPunct("(") {
+						p.next()
+						p.skipBalanced()
+					}
+					p.next()
+				}
+				if !p.isPunct("=") {
+					continue
+				}
+				p.next()
+				r.bindings[name] = p.parseExpr()
+				continue
+			case tok.text == "package" && p.peekAt(1).kind == tokPunct && p.peekAt(1).text == ".":
+				r.warnf(tok.line, "package is modified after it is declared; those changes were not read")
+			}
+		}
+		p.next()
+	}
+}
+
+// resolve follows identifier references to top-level bindings and warns
+// when a value is not a literal.
+func (r *manifestReader) resolve(e *swiftExpr, what string) *swiftExpr {
+	for i := 0; e != nil && e.kind == exprIdent && i < 8; i++ {
+		bound, ok := r.bindings[e.name]
+		if !ok {
+			break
+		}
+		e = bound
+	}
+	if e == nil {
+		return nil
+	}
+	switch e.kind {
+	case exprDynamic:
+		r.warnf(e.line, "%s is computed (%s) and was not read", what, e.source)
+		return nil
+	case exprIdent:
+		r.warnf(e.line, "%s refers to %s, which is not a top-level constant, and was not read", what, e.name)
+		return nil
+	}
+	return e
+}
+
+// str resolves a string literal.
+func (r *manifestReader) str(e *swiftExpr, what string) string {
+	e = r.resolve(e, what)
+	if e == nil {
+		return ""
+	}
+	if e.kind != exprString {
+		r.warnf(e.line, "%s is not a string literal and was not read", what)
+		return ""
+	}
+	return e.name
+}
+
+// array resolves an array literal, returning its resolved elements.
+func (r *manifestReader) array(e *swiftExpr, what string) []*swiftExpr {
+	e = r.resolve(e, what)
+	if e == nil || e.kind == exprNil {
+		return nil
+	}
+	if e.kind != exprArray {
+		r.warnf(e.line, "%s is not an array literal and was not read", what)
+		return nil
+	}
+	var elems []*swiftExpr
+	for _, elem := range e.elems {
+		if elem = r.resolve(elem, what+" element"); elem != nil {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+// stringList resolves an array of string literals.
+func (r *manifestReader) stringList(e *swiftExpr, what string) []string {
+	var values []string
+	for _, elem := range r.array(e, what) {
+		if elem.kind == exprString {
+			values = append(values, elem.name)
+		} else {
+			r.warnf(elem.line, "%s element is not a string literal and was not read", what)
+		}
+	}
+	return values
+}
+
+// readPackage converts the Package(...) call into a manifest.
+func (r *manifestReader) readPackage(pkg *swiftExpr) *PackageManifest {
+	manifest := &PackageManifest{
+		Name:         r.str(pkg.arg("name"), "package name"),
+		Dependencies: []Dependency{},
+		Targets:      []Target{},
+		Products:     []Product{},
+		Platforms:    []Platform{},
+	}
+
+	for _, e := range r.array(pkg.arg("platforms"), "platforms") {
+		if platform, ok := r.readPlatform(e); ok {
+			manifest.Platforms = append(manifest.Platforms, platform)
+		}
+	}
+	for _, e := range r.array(pkg.arg("products"), "products") {
+		if product, ok := r.readProduct(e); ok {
+			manifest.Products = append(manifest.Products, product)
+		}
+	}
+	for _, e := range r.array(pkg.arg("dependencies"), "dependencies") {
+		if dependency, ok := r.readDependency(e); ok {
+			manifest.Dependencies = append(manifest.Dependencies, dependency)
+		}
+	}
+	for _, e := range r.array(pkg.arg("targets"), "targets") {
+		if target, ok := r.readTarget(e); ok {
+			manifest.Targets = append(manifest.Targets, target)
+		}
+	}
+
+	languageVersions := pkg.arg("swiftLanguageVersions")
+	if modes := pkg.arg("swiftLanguageModes"); modes != nil {
+		languageVersions = modes
+	}
+	for _, e := range r.array(languageVersions, "swiftLanguageVersions") {
+		if v := languageVersion(e); v != "" {
+			manifest.SwiftLanguageVersions = append(manifest.SwiftLanguageVersions, SwiftLanguageVersion(v))
+		}
+	}
+
+	if e := r.resolve(pkg.arg("cLanguageStandard"), "cLanguageStandard"); e != nil && e.kind == exprMember {
+		manifest.CLanguageStandard = languageStandard(e.name)
+	}
+	if e := r.resolve(pkg.arg("cxxLanguageStandard"), "cxxLanguageStandard"); e != nil && e.kind == exprMember {
+		manifest.CxxLanguageStandard = languageStandard(e.name)
+	}
+
+	return manifest
+}
+
+// readPlatform reads .macOS(.v12) or .iOS("15.0").
+func (r *manifestReader) readPlatform(e *swiftExpr) (Platform, bool) {
+	if e.kind != exprCall {
+		r.warnf(e.line, "platform %s was not read", e.source)
+		return Platform{}, false
+	}
+	version := r.resolve(e.positional(0), "platform version")
+	if version == nil {
+		return Platform{}, false
+	}
+	platform := Platform{Name: strings.ToLower(e.name), Options: []string{}}
+	switch version.kind {
+	case exprString:
+		platform.Version = version.name
+	case exprMember:
+		platform.Version = memberVersion(version.name, 2)
+	default:
+		r.warnf(e.line, "platform %s version was not read", e.name)
+		return Platform{}, false
+	}
+	return platform, true
+}
+
+// readProduct reads .library, .executable and .plugin products.
+func (r *manifestReader) readProduct(e *swiftExpr) (Product, bool) {
+	if e.kind != exprCall {
+		r.warnf(e.line, "product %s was not read", e.source)
+		return Product{}, false
+	}
+
+	product := Product{
+		Name:    r.str(e.arg("name"), "product name"),
+		Targets: r.stringList(e.arg("targets"), "product targets"),
+	}
+	switch e.name {
+	case "library":
+		product.Type = ProductType{Kind: ProductLibrary, Linkage: "automatic"}
+		if linkage := r.resolve(e.arg("type"), "library type"); linkage != nil && linkage.kind == exprMember {
+			product.Type.Linkage = linkage.name
+		}
+	case "executable":
+		product.Type = ProductType{Kind: ProductExecutable}
+	case "plugin":
+		product.Type = ProductType{Kind: ProductPlugin}
+	default:
+		r.warnf(e.line, "unknown product kind .%s was not read", e.name)
+		return Product{}, false
+	}
+	if product.Targets == nil {
+		product.Targets = []string{}
+	}
+	return product, product.Name != ""
+}
+
+// readDependency reads .package(url:...), .package(path:...) and
+// .package(id:...) declarations.
+func (r *manifestReader) readDependency(e *swiftExpr) (Dependency, bool) {
+	if e.kind != exprCall || e.name != "package" {
+		r.warnf(e.line, "dependency %s was not read", e.source)
+		return Dependency{}, false
+	}
+
+	dep := Dependency{Name: r.str(e.arg("name"), "dependency name")}
+	switch {
+	case e.arg("url") != nil:
+		dep.Kind = DependencySourceControl
+		dep.Location = r.str(e.arg("url"), "dependency url")
+		dep.Identity = identityFromLocation(dep.Location)
+	case e.arg("path") != nil:
+		dep.Kind = DependencyFileSystem
+		dep.Location = r.str(e.arg("path"), "dependency path")
+		if dep.Location != "" && !filepath.IsAbs(dep.Location) {
+			dep.Location = filepath.Join(r.workDir, dep.Location)
+		}
+		dep.Identity = identityFromLocation(dep.Location)
+		return dep, dep.Location != ""
+	case e.arg("id") != nil:
+		dep.Kind = DependencyRegistry
+		dep.Identity = strings.ToLower(r.str(e.arg("id"), "dependency id"))
+	default:
+		r.warnf(e.line, "dependency %s was not read", e.source)
+		return Dependency{}, false
+	}
+	if dep.Identity == "" {
+		return Dependency{}, false
+	}
+
+	requirement, ok := r.readRequirement(e)
+	if !ok {
+		r.warnf(e.line, "version requirement of %s was not read", dep.Identity)
+		return dep, true
+	}
+	dep.Requirement = requirement
+	return dep, true
+}
+
+// readRequirement reads the version requirement of a .package declaration.
+func (r *manifestReader) readRequirement(e *swiftExpr) (*Requirement, bool) {
+	for _, label := range []string{"from", "exact", "branch", "revision"} {
+		value := e.arg(label)
+		if value == nil {
+			continue
+		}
+		s := r.str(value, "dependency "+label)
+		if s == "" {
+			return nil, false
+		}
+		if label == "from" {
+			return &Requirement{Kind: RequirementRange, LowerBound: s, UpperBound: nextMajor(s)}, true
+		}
+		return &Requirement{Kind: label, Value: s}, true
+	}
+
+	// Unlabelled requirement: a range or .upToNextMajor(from:) and friends
+	for _, a := range e.args {
+		if a.label != "" {
+			continue
+		}
+		value := r.resolve(a.value, "dependency requirement")
+		if value == nil {
+			return nil, false
+		}
+		switch value.kind {
+		case exprRange:
+			lower, upper := value.elems[0], value.elems[1]
+			if lower.kind != exprString || upper.kind != exprString {
+				return nil, false
+			}
+			requirement := &Requirement{Kind: RequirementRange, LowerBound: lower.name, UpperBound: upper.name}
+			if value.closed {
+				requirement.UpperBound = nextPatch(upper.name)
+			}
+			return requirement, true
+		case exprCall:
+			arg := r.str(value.positional(0), "dependency requirement")
+			if from := value.arg("from"); from != nil {
+				arg = r.str(from, "dependency requirement")
+			}
+			if arg == "" {
+				return nil, false
+			}
+			switch value.name {
+			case "upToNextMajor":
+				return &Requirement{Kind: RequirementRange, LowerBound: arg, UpperBound: nextMajor(arg)}, true
+			case "upToNextMinor":
+				return &Requirement{Kind: RequirementRange, LowerBound: arg, UpperBound: nextMinor(arg)}, true
+			case "exact", "branch", "revision":
+				return &Requirement{Kind: value.name, Value: arg}, true
+			}
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// targetTypes maps target declaration functions to dump-package types.
+var targetTypes = map[string]string{
+	"target":           "regular",
+	"executableTarget": "executable",
+	"testTarget":       "test",
+	"binaryTarget":     "binary",
+	"systemLibrary":    "system",
+	"plugin":           "plugin",
+	"macro":            "macro",
+}
+
+// readTarget reads a target declaration.
+func (r *manifestReader) readTarget(e *swiftExpr) (Target, bool) {
+	targetType, ok := targetTypes[e.name]
+	if e.kind != exprCall || !ok {
+		r.warnf(e.line, "target %s was not read", e.source)
+		return Target{}, false
+	}
+
+	target := Target{
+		Name:              r.str(e.arg("name"), "target name"),
+		Type:              targetType,
+		Path:              r.str(e.arg("path"), "target path"),
+		URL:               r.str(e.arg("url"), "target url"),
+		Checksum:          r.str(e.arg("checksum"), "target checksum"),
+		Exclude:           r.stringList(e.arg("exclude"), "target exclude"),
+		PublicHeadersPath: r.str(e.arg("publicHeadersPath"), "publicHeadersPath"),
+		Dependencies:      []TargetDependency{},
+	}
+	if target.Name == "" {
+		return Target{}, false
+	}
+	if e.arg("sources") != nil {
+		target.Sources = r.stringList(e.arg("sources"), "target sources")
+	}
+
+	for _, dep := range r.array(e.arg("dependencies"), "target dependencies") {
+		if d, ok := r.readTargetDependency(dep); ok {
+			target.Dependencies = append(target.Dependencies, d)
+		}
+	}
+	for _, res := range r.array(e.arg("resources"), "target resources") {
+		if res.kind != exprCall {
+			r.warnf(res.line, "resource %s was not read", res.source)
+			continue
+		}
+		resource := Resource{Path: r.str(res.positional(0), "resource path"), Rule: res.name}
+		if loc := r.resolve(res.arg("localization"), "resource localization"); loc != nil && loc.kind == exprMember {
+			resource.Localization = loc.name
+		}
+		if resource.Path != "" {
+			target.Resources = append(target.Resources, resource)
+		}
+	}
+	for _, tool := range []string{"c", "cxx", "swift", "linker"} {
+		for _, setting := range r.array(e.arg(tool+"Settings"), tool+"Settings") {
+			if s, ok := r.readSetting(tool, setting); ok {
+				target.Settings = append(target.Settings, s)
+			}
+		}
+	}
+	return target, true
+}
+
+// readTargetDependency reads "Name", .target(name:), .product(name:package:)
+// and .byName(name:) dependencies.
+func (r *manifestReader) readTargetDependency(e *swiftExpr) (TargetDependency, bool) {
+	if e.kind == exprString {
+		return TargetDependency{Kind: TargetDependencyByName, Name: e.name}, true
+	}
+	if e.kind != exprCall {
+		r.warnf(e.line, "target dependency %s was not read", e.source)
+		return TargetDependency{}, false
+	}
+
+	dep := TargetDependency{Kind: e.name, Name: r.str(e.arg("name"), "target dependency name")}
+	switch e.name {
+	case TargetDependencyProduct:
+		dep.Package = r.str(e.arg("package"), "product package")
+	case TargetDependencyTarget, TargetDependencyByName:
+	default:
+		r.warnf(e.line, "target dependency %s was not read", e.source)
+		return TargetDependency{}, false
+	}
+	if condition := r.resolve(e.arg("condition"), "dependency condition"); condition != nil && condition.kind == exprCall {
+		dep.Platforms = r.platformNames(condition.arg("platforms"))
+	}
+	return dep, dep.Name != ""
+}
+
+// readSetting reads a build setting such as .define("DEBUG", .when(...)).
+func (r *manifestReader) readSetting(tool string, e *swiftExpr) (TargetSetting, bool) {
+	if e.kind != exprCall {
+		r.warnf(e.line, "%s setting %s was not read", tool, e.source)
+		return TargetSetting{}, false
+	}
+
+	setting := TargetSetting{Tool: tool, Kind: e.name}
+	for _, a := range e.args {
+		value := r.resolve(a.value, tool+" setting")
+		if value == nil {
+			continue
+		}
+		switch {
+		case value.kind == exprString:
+			setting.Values = append(setting.Values, value.name)
+		case value.kind == exprArray:
+			setting.Values = append(setting.Values, r.stringList(value, tool+" setting")...)
+		case value.kind == exprCall && value.name == "when":
+			setting.Platforms = r.platformNames(value.arg("platforms"))
+			if config := r.resolve(value.arg("configuration"), "setting configuration"); config != nil && config.kind == exprMember {
+				setting.Configuration = config.name
+			}
+		case value.kind == exprMember:
+			setting.Values = append(setting.Values, value.name)
+		}
+	}
+	return setting, true
+}
+
+// platformNames reads a [.macOS, .linux] platform list.
+func (r *manifestReader) platformNames(e *swiftExpr) []string {
+	var names []string
+	for _, elem := range r.array(e, "platforms") {
+		if elem.kind == exprMember {
+			names = append(names, strings.ToLower(elem.name))
+		}
+	}
+	return names
+}
+
+// languageVersion reads .v5, .v4_2 or .version("6").
+func languageVersion(e *swiftExpr) string {
+	switch {
+	case e.kind == exprMember:
+		return memberVersion(e.name, 1)
+	case e.kind == exprCall && e.name == "version":
+		if v := e.positional(0); v != nil && v.kind == exprString {
+			return v.name
+		}
+	case e.kind == exprString:
+		return e.name
+	}
+	return ""
+}
+
+// languageStandard converts .cxx17 or .gnucxx20 to the dump-package spelling.
+func languageStandard(member string) string {
+	return strings.NewReplacer("gnucxx", "gnu++", "cxx", "c++").Replace(member)
+}
+
+// memberVersion converts a version member such as v10_15 into "10.15",
+// padding to at least minComponents components.
+func memberVersion(member string, minComponents int) string {
+	parts := strings.Split(strings.TrimPrefix(member, "v"), "_")
+	for len(parts) < minComponents {
+		parts = append(parts, "0")
+	}
+	return strings.Join(parts, ".")
+}
+
+// identityFromLocation derives a package identity from a URL or path the way
+// SwiftPM does: the last path component, without .git, in lower case.
+func identityFromLocation(location string) string {
+	location = strings.TrimSuffix(strings.TrimRight(location, "/"), ".git")
+	return strings.ToLower(path.Base(filepath.ToSlash(location)))
+}
+
+// normalizeVersion pads a version to major.minor.patch.
+func normalizeVersion(v string) string {
+	parts := strings.Split(v, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	return strings.Join(parts, ".")
+}
+
+// versionComponents parses the numeric major, minor and patch components.
+func versionComponents(v string) (int, int, int, bool) {
+	core, _, _ := strings.Cut(v, "-")
+	core, _, _ = strings.Cut(core, "+")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		nums[i] = n
+	}
+	return nums[0], nums[1], nums[2], true
+}
+
+// nextMajor returns the exclusive upper bound of .upToNextMajor(from: v).
+func nextMajor(v string) string {
+	major, _, _, ok := versionComponents(v)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d.0.0", major+1)
+}
+
+// nextMinor returns the exclusive upper bound of .upToNextMinor(from: v).
+func nextMinor(v string) string {
+	major, minor, _, ok := versionComponents(v)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.0", major, minor+1)
+}
+
+// nextPatch returns the exclusive upper bound SwiftPM uses for a closed
+// range ending at v.
+func nextPatch(v string) string {
+	major, minor, patch, ok := versionComponents(v)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch+1)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestParseManifestStatic_Golden checks the static reading of the manifests
+// in testdata/package-swift. Run with -update to regenerate the golden files.
+func TestParseManifestStatic_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "package-swift", "*.swift"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".swift")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest, warnings, err := parseManifestSource(string(source), "/src/"+name)
+			if err != nil {
+				t.Fatalf("parseManifestSource() error = %v", err)
+			}
+
+			got, err := json.MarshalIndent(struct {
+				Manifest *PackageManifest `json:"manifest"`
+				Warnings []string         `json:"warnings"`
+			}{manifest, warnings}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(file, ".swift") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("static manifest mismatch for %s:\n%s", name, got)
+			}
+		})
+	}
+}
+
+func TestParseManifestStatic_Requirements(t *testing.T) {
+	tests := []struct {
+		name string
+		decl string
+		want Requirement
+	}{
+		{"from", `.package(url: "https://x/a.git", from: "1.2.3")`, Requirement{Kind: RequirementRange, LowerBound: "1.2.3", UpperBound: "2.0.0"}},
+		{"pre-1.0 from", `.package(url: "https://x/a.git", from: "0.4.0")`, Requirement{Kind: RequirementRange, LowerBound: "0.4.0", UpperBound: "1.0.0"}},
+		{"upToNextMajor", `.package(url: "https://x/a.git", .upToNextMajor(from: "3.1.0"))`, Requirement{Kind: RequirementRange, LowerBound: "3.1.0", UpperBound: "4.0.0"}},
+		{"upToNextMinor", `.package(url: "https://x/a.git", .upToNextMinor(from: "3.1.4"))`, Requirement{Kind: RequirementRange, LowerBound: "3.1.4", UpperBound: "3.2.0"}},
+		{"half-open range", `.package(url: "https://x/a.git", "1.0.0"..<"1.5.0")`, Requirement{Kind: RequirementRange, LowerBound: "1.0.0", UpperBound: "1.5.0"}},
+		{"closed range", `.package(url: "https://x/a.git", "1.0.0"..."1.4.2")`, Requirement{Kind: RequirementRange, LowerBound: "1.0.0", UpperBound: "1.4.3"}},
+		{"exact label", `.package(url: "https://x/a.git", exact: "1.0.0")`, Requirement{Kind: RequirementExact, Value: "1.0.0"}},
+		{"exact call", `.package(url: "https://x/a.git", .exact("1.0.0"))`, Requirement{Kind: RequirementExact, Value: "1.0.0"}},
+		{"branch call", `.package(url: "https://x/a.git", .branch("develop"))`, Requirement{Kind: RequirementBranch, Value: "develop"}},
+		{"revision", `.package(url: "https://x/a.git", revision: "abc123")`, Requirement{Kind: RequirementRevision, Value: "abc123"}},
+		{"constant", `.package(url: "https://x/a.git", from: minimum)`, Requirement{Kind: RequirementRange, LowerBound: "2.0.0", UpperBound: "3.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "// swift-tools-version:5.9\nlet minimum = \"2.0.0\"\nlet package = Package(name: \"P\", dependencies: [" + tt.decl + "])\n"
+			manifest, warnings, err := parseManifestSource(source, "/src")
+			if err != nil {
+				t.Fatalf("parseManifestSource() error = %v", err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+			if len(manifest.Dependencies) != 1 || manifest.Dependencies[0].Requirement == nil {
+				t.Fatalf("dependencies = %+v", manifest.Dependencies)
+			}
+			if got := *manifest.Dependencies[0].Requirement; got != tt.want {
+				t.Errorf("requirement = %+v, want %+v", got, tt.want)
+			}
+			if got := manifest.Dependencies[0].Identity; got != "a" {
+				t.Errorf("identity = %q, want a", got)
+			}
+		})
+	}
+}
+
+func TestParseManifestStatic_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"no package", "import PackageDescription\n", "no `let package = Package(...)`"},
+		{"computed name", "let package = Package(name: \"a\" + \"b\")\n", "package name is not a string literal"},
+		{"unterminated string", "let package = Package(name: \"a)\n", "unterminated string literal"},
+		{"unterminated comment", "/* let package = Package(name: \"a\")\n", "unterminated comment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseManifestSource(tt.source, "/src")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseManifestSource() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadManifest_WithoutSwift(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	dir := t.TempDir()
+	manifest := "// swift-tools-version:5.9\nimport PackageDescription\nlet package = Package(name: \"Offline\", targets: [.target(name: \"Offline\")])\n"
+	if err := os.WriteFile(filepath.Join(dir, "Package.swift"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, warnings, err := LoadManifest(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if got.Name != "Offline" || got.ToolsVersion.String() != "5.9.0" {
+		t.Errorf("manifest = %+v", got)
+	}
+	if !slices.ContainsFunc(warnings, func(w string) bool { return strings.Contains(w, "Swift toolchain not found") }) {
+		t.Errorf("warnings = %v, want toolchain warning", warnings)
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
+var update = flag.Bool("update", false, "update golden files in testdata")
+
 func TestUpdateVersionConstant(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -174,3 +182,165 @@ let package = Package(name: "Test")`,
 		})
 	}
 }
+
+// TestDecodeManifest_Golden decodes `swift package dump-package` output in
+// the formats of several SwiftPM versions and compares the result with the
+// matching .golden file. Run with -update to regenerate the golden files,
+// and review their diff: they are expectations, not a copy of the code's
+// output. TestParseManifest_WithSwift covers the installed toolchain.
+func TestDecodeManifest_Golden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "dump-package", "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list testdata: %v", err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no dump-package testdata found")
+	}
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("failed to read input: %v", err)
+			}
+			manifest, err := decodeManifest(data)
+			if err != nil {
+				t.Fatalf("decodeManifest failed: %v", err)
+			}
+
+			got, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				t.Fatalf("failed to encode manifest: %v", err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(input, ".json") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("decoded manifest does not match %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// TestParseManifest_WithSwift decodes the dump-package output of the
+// installed toolchain.
+func TestParseManifest_WithSwift(t *testing.T) {
+	if _, err := exec.LookPath("swift"); err != nil {
+		t.Skip("Swift CLI not available")
+	}
+
+	dir := writeManifests(t, map[string]string{"Package.swift": `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    platforms: [.macOS(.v13)],
+    products: [.library(name: "Kit", type: .static, targets: ["Kit"])],
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-log.git", from: "1.5.0"),
+    ],
+    targets: [
+        .target(name: "Kit", dependencies: [.product(name: "Logging", package: "swift-log")]),
+    ]
+)
+`})
+
+	manifest, err := ParseManifest(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+	if got := manifest.Products[0].Type; got != (ProductType{Kind: ProductLibrary, Linkage: "static"}) {
+		t.Errorf("product type = %+v", got)
+	}
+	dep := manifest.Dependencies[0]
+	if dep.Kind != DependencySourceControl || dep.Location != "https://github.com/apple/swift-log.git" {
+		t.Errorf("dependency = %+v", dep)
+	}
+	if dep.Requirement == nil || *dep.Requirement != (Requirement{Kind: RequirementRange, LowerBound: "1.5.0", UpperBound: "2.0.0"}) {
+		t.Errorf("requirement = %+v", dep.Requirement)
+	}
+	if got := manifest.Targets[0].Dependencies; len(got) != 1 || got[0].Kind != TargetDependencyProduct || got[0].Package != "swift-log" {
+		t.Errorf("target dependencies = %+v", got)
+	}
+}
+
+// TestDecodeManifest_RoundTrip checks that a marshalled manifest decodes
+// back to the same manifest.
+func TestDecodeManifest_RoundTrip(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "dump-package", "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list testdata: %v", err)
+	}
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("failed to read input: %v", err)
+			}
+			manifest, err := decodeManifest(data)
+			if err != nil {
+				t.Fatalf("decodeManifest failed: %v", err)
+			}
+
+			encoded, err := json.Marshal(manifest)
+			if err != nil {
+				t.Fatalf("failed to encode manifest: %v", err)
+			}
+			var decoded PackageManifest
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("failed to decode marshalled manifest: %v", err)
+			}
+			// Empty lists decode as nil, so the encodings are compared
+			reencoded, err := json.Marshal(&decoded)
+			if err != nil {
+				t.Fatalf("failed to encode manifest: %v", err)
+			}
+			if !bytes.Equal(reencoded, encoded) {
+				t.Errorf("round trip changed the manifest:\n got %s\nwant %s", reencoded, encoded)
+			}
+		})
+	}
+}
+
+func TestDecodeManifest_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "unknown dependency kind",
+			input:   `{"name": "P", "dependencies": [{"svn": [{"identity": "x"}]}]}`,
+			wantErr: `unknown dependency kind "svn"`,
+		},
+		{
+			name:    "malformed product type",
+			input:   `{"name": "P", "products": [{"name": "P", "type": {"library": null, "executable": null}}]}`,
+			wantErr: "invalid product type",
+		},
+		{
+			name:    "malformed requirement",
+			input:   `{"name": "P", "dependencies": [{"registry": [{"identity": "a.b", "requirement": {"range": []}}]}]}`,
+			wantErr: "invalid range requirement",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeManifest([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ManifestVariant is Package.swift or a version-specific manifest such as
+// Package@swift-5.9.swift next to it. SwiftPM loads the variant with the
+// highest version not newer than the running toolchain.
+type ManifestVariant struct {
+	// Path is the location of the manifest file.
+	Path string `json:"-"`
+	// Name is the manifest file name.
+	Name string `json:"name"`
+	// SwiftVersion is the X in Package@swift-X.swift, empty for Package.swift.
+	SwiftVersion string `json:"swift_version,omitempty"`
+	// ToolsVersion is the manifest's swift-tools-version.
+	ToolsVersion string `json:"tools_version"`
+}
+
+// manifestVariantPattern matches Package@swift-5.swift, Package@swift-5.9.swift
+// and Package@swift-5.9.1.swift.
+var manifestVariantPattern = regexp.MustCompile(`^Package@swift-(\d+(?:\.\d+){0,2})\.swift$`)
+
+// FindManifestVariants returns the manifest at manifestPath followed by the
+// version-specific manifests in the same directory, ordered by Swift version,
+// each with its swift-tools-version.
+func FindManifestVariants(manifestPath string) ([]ManifestVariant, error) {
+	dir := filepath.Dir(manifestPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	variants := []ManifestVariant{{Path: manifestPath, Name: filepath.Base(manifestPath)}}
+	for _, entry := range entries {
+		match := manifestVariantPattern.FindStringSubmatch(entry.Name())
+		if match == nil || !entry.Type().IsRegular() {
+			continue
+		}
+		variants = append(variants, ManifestVariant{
+			Path:         filepath.Join(dir, entry.Name()),
+			Name:         entry.Name(),
+			SwiftVersion: match[1],
+		})
+	}
+	slices.SortStableFunc(variants[1:], func(a, b ManifestVariant) int {
+		return compareDottedVersions(a.SwiftVersion, b.SwiftVersion)
+	})
+
+	for i := range variants {
+		toolsVersion, err := ExtractSwiftToolsVersion(variants[i].Path)
+		if err != nil {
+			return nil, err
+		}
+		variants[i].ToolsVersion = toolsVersion
+	}
+	return variants, nil
+}
+
+// compareDottedVersions compares numeric dotted versions such as 5.9 and
+// 5.10, treating missing components as zero.
+func compareDottedVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// manifestVariantNames returns the file names of the variants.
+func manifestVariantNames(variants []ManifestVariant) []string {
+	names := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		names = append(names, variant.Name)
+	}
+	return names
+}
+
+// UpdateVersionConstants updates the version constant in every manifest
+// variant so they cannot drift apart. No file is written unless every
+// variant declares the constant.
+func UpdateVersionConstants(variants []ManifestVariant, constantName, version string) error {
+	contents := make([][]byte, len(variants))
+	for i, variant := range variants {
+		content, err := os.ReadFile(variant.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", variant.Path, err)
+		}
+		contents[i], err = replaceVersionConstant(content, constantName, version)
+		if err != nil {
+			return fmt.Errorf("%w in %s", err, variant.Path)
+		}
+	}
+
+	for i, variant := range variants {
+		if err := os.WriteFile(variant.Path, contents[i], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", variant.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeManifests creates files with the given contents in a temp directory.
+func writeManifests(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestFindManifestVariants(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"Package.swift":            "// swift-tools-version:5.7\n",
+		"Package@swift-5.10.swift": "// swift-tools-version:5.10\n",
+		"Package@swift-5.9.swift":  "// swift-tools-version: 5.9\n",
+		"Package@swift-6.swift":    "// swift-tools-version:6.0\n",
+		"Package@swift-next.swift": "// swift-tools-version:6.1\n",
+		"Package.resolved":         "{}",
+	})
+
+	variants, err := FindManifestVariants(filepath.Join(dir, "Package.swift"))
+	if err != nil {
+		t.Fatalf("FindManifestVariants() error = %v", err)
+	}
+
+	var got []string
+	for _, v := range variants {
+		got = append(got, v.Name+"="+v.SwiftVersion+"/"+v.ToolsVersion)
+	}
+	want := []string{
+		"Package.swift=/5.7",
+		"Package@swift-5.9.swift=5.9/5.9",
+		"Package@swift-5.10.swift=5.10/5.10",
+		"Package@swift-6.swift=6/6.0",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("variants = %v, want %v", got, want)
+	}
+}
+
+func TestFindManifestVariants_MissingToolsVersion(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"Package.swift":           "// swift-tools-version:5.7\n",
+		"Package@swift-6.0.swift": "import PackageDescription\n",
+	})
+
+	_, err := FindManifestVariants(filepath.Join(dir, "Package.swift"))
+	if err == nil || !strings.Contains(err.Error(), "Package@swift-6.0.swift") {
+		t.Errorf("expected error naming the variant, got %v", err)
+	}
+}
+
+func TestUpdateVersionConstants(t *testing.T) {
+	const manifest = "// swift-tools-version:5.9\nlet packageVersion = \"1.0.0\"\n"
+
+	t.Run("updates every variant", func(t *testing.T) {
+		dir := writeManifests(t, map[string]string{
+			"Package.swift":           manifest,
+			"Package@swift-6.0.swift": manifest,
+		})
+		variants, err := FindManifestVariants(filepath.Join(dir, "Package.swift"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := UpdateVersionConstants(variants, "packageVersion", "2.0.0"); err != nil {
+			t.Fatalf("UpdateVersionConstants() error = %v", err)
+		}
+		for _, v := range variants {
+			content, _ := os.ReadFile(v.Path)
+			if !strings.Contains(string(content), `let packageVersion = "2.0.0"`) {
+				t.Errorf("%s not updated:\n%s", v.Name, content)
+			}
+		}
+	})
+
+	t.Run("writes nothing when a variant lacks the constant", func(t *testing.T) {
+		dir := writeManifests(t, map[string]string{
+			"Package.swift":           manifest,
+			"Package@swift-6.0.swift": "// swift-tools-version:6.0\n",
+		})
+		variants, err := FindManifestVariants(filepath.Join(dir, "Package.swift"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = UpdateVersionConstants(variants, "packageVersion", "2.0.0")
+		if err == nil || !strings.Contains(err.Error(), "Package@swift-6.0.swift") {
+			t.Fatalf("expected error naming the variant, got %v", err)
+		}
+		content, _ := os.ReadFile(filepath.Join(dir, "Package.swift"))
+		if string(content) != manifest {
+			t.Errorf("Package.swift was modified:\n%s", content)
+		}
+	})
+}
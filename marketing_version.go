@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// MarketingVersionConfig keeps the marketing version of companion apps and
+// Xcode projects in step with the package release.
+type MarketingVersionConfig struct {
+	// Paths are slash-separated globs, relative to the package root, of
+	// project.pbxproj, .xcconfig and Info.plist files.
+	Paths []string `json:"paths"`
+	// Template renders the marketing version; defaults to
+	// {{.Major}}.{{.Minor}}.{{.Patch}}, as App Store Connect only accepts
+	// numeric versions.
+	Template string `json:"template"`
+}
+
+// defaultMarketingVersionTemplate is the numeric release version.
+const defaultMarketingVersionTemplate = "{{.Major}}.{{.Minor}}.{{.Patch}}"
+
+// validate reports a configuration problem, or "".
+func (c MarketingVersionConfig) validate() string {
+	for _, glob := range c.Paths {
+		if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+			return fmt.Sprintf("invalid path glob %q", glob)
+		}
+		if marketingVersionFormat(glob) == nil {
+			return fmt.Sprintf("path %q must match project.pbxproj, .xcconfig or Info.plist files", glob)
+		}
+	}
+	if c.Template != "" {
+		if _, err := newTemplate("marketing_version.template", c.Template); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// marketingVersionSetting locates a marketing version in one file format.
+// The pattern's first group is the text before the value and the second the
+// value itself.
+type marketingVersionSetting struct {
+	name    string
+	pattern *regexp.Regexp
+	// format renders a new value in the file's syntax given the old one.
+	format func(old, value string) string
+}
+
+var (
+	// MARKETING_VERSION = 1.0; or MARKETING_VERSION = "1.0 beta";
+	pbxprojMarketingVersion = marketingVersionSetting{
+		name:    "MARKETING_VERSION",
+		pattern: regexp.MustCompile(`(\bMARKETING_VERSION\s*=\s*)("(?:[^"\\\n]|\\.)*"|[^;\s]+)\s*;`),
+		format:  formatPbxprojValue,
+	}
+	// MARKETING_VERSION = 1.0, including conditional settings such as
+	// MARKETING_VERSION[sdk=iphoneos*] = 1.0, up to a trailing comment
+	xcconfigMarketingVersion = marketingVersionSetting{
+		name:    "MARKETING_VERSION",
+		pattern: regexp.MustCompile(`(?m)(^[ \t]*MARKETING_VERSION(?:\[[^\]\n]*\])*[ \t]*=[ \t]*)((?:[^/\n]|/[^/\n])*?)[ \t]*(?://.*)?$`),
+		format:  func(_, value string) string { return value },
+	}
+	// <key>CFBundleShortVersionString</key> <string>1.0</string>
+	plistShortVersionString = marketingVersionSetting{
+		name:    "CFBundleShortVersionString",
+		pattern: regexp.MustCompile(`(<key>CFBundleShortVersionString</key>\s*<string>)([^<]*)</string>`),
+		format: func(_, value string) string {
+			return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+		},
+	}
+)
+
+// marketingVersionFormat returns the setting for a file or glob by its file
+// name, or nil if the format is not supported.
+func marketingVersionFormat(name string) *marketingVersionSetting {
+	base := path.Base(name)
+	switch {
+	case strings.HasSuffix(base, ".pbxproj"):
+		return &pbxprojMarketingVersion
+	case strings.HasSuffix(base, ".xcconfig"):
+		return &xcconfigMarketingVersion
+	case strings.HasSuffix(base, ".plist"):
+		return &plistShortVersionString
+	default:
+		return nil
+	}
+}
+
+// formatPbxprojValue quotes value when the old value was quoted or when the
+// value is not a plain pbxproj word.
+func formatPbxprojValue(old, value string) string {
+	if strings.HasPrefix(old, `"`) || strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_./") != "" {
+		return swiftStringLiteral(value)
+	}
+	return value
+}
+
+// UpdateMarketingVersions sets MARKETING_VERSION in project.pbxproj and
+// .xcconfig files and CFBundleShortVersionString in Info.plist files under
+// workDir, returning every replacement. Values that refer to another build
+// setting, such as $(MARKETING_VERSION), are left alone. Files are only
+// written when write is set and every glob matched a file with a setting.
+func UpdateMarketingVersions(workDir string, cfg MarketingVersionConfig, data templateData, write bool) ([]VersionReplacement, error) {
+	text := cfg.Template
+	if text == "" {
+		text = defaultMarketingVersionTemplate
+	}
+	value, err := renderTemplate("marketing_version.template", text, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var replacements []VersionReplacement
+	edits := newFileEdits(workDir)
+	for _, glob := range cfg.Paths {
+		files, err := globFiles(workDir, glob)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, file := range files {
+			setting := marketingVersionFormat(file)
+			if setting == nil {
+				continue
+			}
+			content, err := edits.read(file)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.HasPrefix(content, []byte("bplist")) {
+				return nil, fmt.Errorf("%s is a binary property list; convert it to XML with `plutil -convert xml1`", file)
+			}
+
+			content, fileReplacements, matched := setting.replace(content, value)
+			for i := range fileReplacements {
+				fileReplacements[i].File = file
+			}
+			replacements = append(replacements, fileReplacements...)
+			edits.set(file, content)
+			found = found || matched
+		}
+		if !found {
+			return nil, fmt.Errorf("no MARKETING_VERSION or CFBundleShortVersionString found in files matching %q", glob)
+		}
+	}
+
+	if write {
+		if err := edits.write(); err != nil {
+			return nil, err
+		}
+	}
+	return replacements, nil
+}
+
+// replace sets every occurrence of the setting to value. matched reports
+// whether the setting occurs at all, including values left alone because
+// they refer to another build setting.
+func (s marketingVersionSetting) replace(content []byte, value string) ([]byte, []VersionReplacement, bool) {
+	var replacements []VersionReplacement
+	var out bytes.Buffer
+	last := 0
+	matches := s.pattern.FindAllSubmatchIndex(content, -1)
+	for _, m := range matches {
+		old := string(content[m[4]:m[5]])
+		if strings.Contains(old, "$(") || strings.Contains(old, "${") {
+			continue
+		}
+		formatted := s.format(old, value)
+
+		out.Write(content[last:m[4]])
+		out.WriteString(formatted)
+		last = m[5]
+
+		replacements = append(replacements, VersionReplacement{
+			Line: lineAt(content, m[4]),
+			Name: s.name,
+			Old:  old,
+			New:  formatted,
+		})
+	}
+	out.Write(content[last:])
+	return out.Bytes(), replacements, len(matches) > 0
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPbxproj = `		4A1B2C3D /* Debug */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				CURRENT_PROJECT_VERSION = 7;
+				MARKETING_VERSION = 1.0;
+				PRODUCT_NAME = "$(TARGET_NAME)";
+			};
+		};
+		4A1B2C3E /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				MARKETING_VERSION = "1.0";
+			};
+		};
+		4A1B2C3F /* Widget */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				MARKETING_VERSION = "$(APP_MARKETING_VERSION)";
+			};
+		};
+`
+
+const testXcconfig = `// Shared settings
+MARKETING_VERSION = 1.0 // bumped on release
+MARKETING_VERSION[sdk=macosx*] = 1.0
+CURRENT_PROJECT_VERSION = 7
+`
+
+const testInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleShortVersionString</key>
+	<string>1.0</string>
+	<key>CFBundleVersion</key>
+	<string>7</string>
+</dict>
+</plist>
+`
+
+func TestUpdateMarketingVersions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Example/Example.xcodeproj/project.pbxproj": testPbxproj,
+		"Example/Config/Shared.xcconfig":            testXcconfig,
+		"Example/Example/Info.plist":                testInfoPlist,
+		"Example/Widget/Info.plist":                 strings.Replace(testInfoPlist, "<string>1.0</string>", "<string>$(MARKETING_VERSION)</string>", 1),
+	})
+
+	replacements, err := UpdateMarketingVersions(dir, MarketingVersionConfig{
+		Paths: []string{"Example/*.xcodeproj/project.pbxproj", "Example/**/*.xcconfig", "Example/**/Info.plist"},
+	}, newTemplateData("2.3.1-beta.1", "v"), true)
+	if err != nil {
+		t.Fatalf("UpdateMarketingVersions() error = %v", err)
+	}
+
+	pbxproj, _ := os.ReadFile(filepath.Join(dir, "Example/Example.xcodeproj/project.pbxproj"))
+	wantPbxproj := strings.NewReplacer(
+		"MARKETING_VERSION = 1.0;", "MARKETING_VERSION = 2.3.1;",
+		`MARKETING_VERSION = "1.0";`, `MARKETING_VERSION = "2.3.1";`,
+	).Replace(testPbxproj)
+	if string(pbxproj) != wantPbxproj {
+		t.Errorf("project.pbxproj =\n%s\nwant\n%s", pbxproj, wantPbxproj)
+	}
+
+	xcconfig, _ := os.ReadFile(filepath.Join(dir, "Example/Config/Shared.xcconfig"))
+	wantXcconfig := strings.ReplaceAll(testXcconfig, "= 1.0", "= 2.3.1")
+	if string(xcconfig) != wantXcconfig {
+		t.Errorf("Shared.xcconfig =\n%s\nwant\n%s", xcconfig, wantXcconfig)
+	}
+
+	plist, _ := os.ReadFile(filepath.Join(dir, "Example/Example/Info.plist"))
+	if want := strings.Replace(testInfoPlist, "<string>1.0</string>", "<string>2.3.1</string>", 1); string(plist) != want {
+		t.Errorf("Info.plist =\n%s", plist)
+	}
+	widget, _ := os.ReadFile(filepath.Join(dir, "Example/Widget/Info.plist"))
+	if !strings.Contains(string(widget), "<string>$(MARKETING_VERSION)</string>") {
+		t.Errorf("build setting reference was replaced:\n%s", widget)
+	}
+
+	if len(replacements) != 5 {
+		t.Fatalf("expected 5 replacements, got %d: %+v", len(replacements), replacements)
+	}
+	want := []VersionReplacement{
+		{File: "Example/Example.xcodeproj/project.pbxproj", Line: 5, Name: "MARKETING_VERSION", Old: "1.0", New: "2.3.1"},
+		{File: "Example/Example.xcodeproj/project.pbxproj", Line: 12, Name: "MARKETING_VERSION", Old: `"1.0"`, New: `"2.3.1"`},
+		{File: "Example/Config/Shared.xcconfig", Line: 2, Name: "MARKETING_VERSION", Old: "1.0", New: "2.3.1"},
+		{File: "Example/Config/Shared.xcconfig", Line: 3, Name: "MARKETING_VERSION", Old: "1.0", New: "2.3.1"},
+		{File: "Example/Example/Info.plist", Line: 6, Name: "CFBundleShortVersionString", Old: "1.0", New: "2.3.1"},
+	}
+	for i, r := range replacements {
+		if r != want[i] {
+			t.Errorf("replacement %d = %+v, want %+v", i, r, want[i])
+		}
+	}
+}
+
+func TestUpdateMarketingVersions_Template(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"App.xcodeproj/project.pbxproj": "MARKETING_VERSION = 1.0;\n",
+		"App/Info.plist":                testInfoPlist,
+	})
+
+	_, err := UpdateMarketingVersions(dir, MarketingVersionConfig{
+		Paths:    []string{"App.xcodeproj/project.pbxproj", "App/Info.plist"},
+		Template: "{{.Version}} <{{.Tag}}>",
+	}, newTemplateData("2.0.0-rc.1", "v"), true)
+	if err != nil {
+		t.Fatalf("UpdateMarketingVersions() error = %v", err)
+	}
+
+	pbxproj, _ := os.ReadFile(filepath.Join(dir, "App.xcodeproj/project.pbxproj"))
+	if want := "MARKETING_VERSION = \"2.0.0-rc.1 <v2.0.0-rc.1>\";\n"; string(pbxproj) != want {
+		t.Errorf("project.pbxproj = %q, want %q", pbxproj, want)
+	}
+	plist, _ := os.ReadFile(filepath.Join(dir, "App/Info.plist"))
+	if !strings.Contains(string(plist), "<string>2.0.0-rc.1 &lt;v2.0.0-rc.1&gt;</string>") {
+		t.Errorf("Info.plist value not escaped:\n%s", plist)
+	}
+}
+
+func TestUpdateMarketingVersions_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"Shared.xcconfig": testXcconfig})
+
+	replacements, err := UpdateMarketingVersions(dir, MarketingVersionConfig{Paths: []string{"*.xcconfig"}},
+		newTemplateData("3.0.0", ""), false)
+	if err != nil {
+		t.Fatalf("UpdateMarketingVersions() error = %v", err)
+	}
+	if len(replacements) != 2 || replacements[0].New != "3.0.0" {
+		t.Errorf("unexpected replacements %+v", replacements)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "Shared.xcconfig")); string(content) != testXcconfig {
+		t.Errorf("dry run modified the file:\n%s", content)
+	}
+}
+
+func TestUpdateMarketingVersions_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		paths   []string
+		wantErr string
+	}{
+		{"no matching files", nil, []string{"Missing/Info.plist"}, `found in files matching "Missing/Info.plist"`},
+		{"no setting", map[string]string{"Other.xcconfig": "CURRENT_PROJECT_VERSION = 7\n"}, []string{"Other.xcconfig"}, "no MARKETING_VERSION"},
+		{"binary plist", map[string]string{"Binary/Info.plist": "bplist00\x00\x01"}, []string{"Binary/Info.plist"}, "binary property list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{"App/Info.plist": testInfoPlist})
+			writeTestFiles(t, dir, tt.files)
+
+			_, err := UpdateMarketingVersions(dir, MarketingVersionConfig{Paths: append([]string{"App/Info.plist"}, tt.paths...)},
+				newTemplateData("2.0.0", ""), true)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			// Earlier paths are not written when a later one fails
+			if content, _ := os.ReadFile(filepath.Join(dir, "App/Info.plist")); string(content) != testInfoPlist {
+				t.Errorf("file modified despite error:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestMarketingVersionConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MarketingVersionConfig
+		want string
+	}{
+		{"disabled", MarketingVersionConfig{}, ""},
+		{"supported files", MarketingVersionConfig{Paths: []string{"*.xcodeproj/project.pbxproj", "Config/*.xcconfig", "**/Info.plist"}}, ""},
+		{"unsupported file", MarketingVersionConfig{Paths: []string{"Sources/**/*.swift"}}, "must match"},
+		{"bad glob", MarketingVersionConfig{Paths: []string{"[/Info.plist"}}, "invalid path glob"},
+		{"bad template", MarketingVersionConfig{Paths: []string{"Info.plist"}, Template: "{{.Major"}, "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.validate()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
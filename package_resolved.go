@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Pin kinds written by SwiftPM 5.6 and later. Version 1 files only pin
+// remote repositories.
+const (
+	PinRemoteSourceControl = "remoteSourceControl"
+	PinLocalSourceControl  = "localSourceControl"
+	PinRegistry            = "registry"
+)
+
+// PackageResolved is the content of a Package.resolved file.
+type PackageResolved struct {
+	// Version is the file format: 1 (SwiftPM 5.5 and earlier), 2 (5.6) or
+	// 3 (5.10, which adds OriginHash).
+	Version    int           `json:"version"`
+	OriginHash string        `json:"originHash,omitempty"`
+	Pins       []ResolvedPin `json:"pins"`
+}
+
+// ResolvedPin is the resolved state of one package.
+type ResolvedPin struct {
+	// Identity is the lowercase package identity.
+	Identity string `json:"identity"`
+	// Kind is one of the Pin* constants.
+	Kind     string `json:"kind"`
+	Location string `json:"location"`
+	Version  string `json:"version,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// pinState is the state object shared by every format.
+type pinState struct {
+	Version  string `json:"version"`
+	Branch   string `json:"branch"`
+	Revision string `json:"revision"`
+}
+
+// LoadPackageResolved reads the Package.resolved in workDir. It returns nil
+// without error when the package has none.
+func LoadPackageResolved(workDir string) (*PackageResolved, error) {
+	data, err := os.ReadFile(filepath.Join(workDir, "Package.resolved"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Package.resolved: %w", err)
+	}
+	resolved, err := ParsePackageResolved(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Package.resolved: %w", err)
+	}
+	return resolved, nil
+}
+
+// ParsePackageResolved parses a version 1, 2 or 3 Package.resolved file.
+func ParsePackageResolved(data []byte) (*PackageResolved, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	resolved := &PackageResolved{Version: header.Version, Pins: []ResolvedPin{}}
+	switch header.Version {
+	case 1:
+		var file struct {
+			Object struct {
+				Pins []struct {
+					Package       string   `json:"package"`
+					RepositoryURL string   `json:"repositoryURL"`
+					State         pinState `json:"state"`
+				} `json:"pins"`
+			} `json:"object"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		for _, pin := range file.Object.Pins {
+			resolved.Pins = append(resolved.Pins, ResolvedPin{
+				Identity: identityFromLocation(pin.RepositoryURL),
+				Kind:     PinRemoteSourceControl,
+				Location: pin.RepositoryURL,
+				Version:  pin.State.Version,
+				Branch:   pin.State.Branch,
+				Revision: pin.State.Revision,
+			})
+		}
+	case 2, 3:
+		var file struct {
+			OriginHash string `json:"originHash"`
+			Pins       []struct {
+				Identity string   `json:"identity"`
+				Kind     string   `json:"kind"`
+				Location string   `json:"location"`
+				State    pinState `json:"state"`
+			} `json:"pins"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		resolved.OriginHash = file.OriginHash
+		for _, pin := range file.Pins {
+			resolved.Pins = append(resolved.Pins, ResolvedPin{
+				Identity: strings.ToLower(pin.Identity),
+				Kind:     pin.Kind,
+				Location: pin.Location,
+				Version:  pin.State.Version,
+				Branch:   pin.State.Branch,
+				Revision: pin.State.Revision,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported version %d", header.Version)
+	}
+	return resolved, nil
+}
+
+// Pin returns the pin for a package identity, or nil.
+func (r *PackageResolved) Pin(identity string) *ResolvedPin {
+	identity = strings.ToLower(identity)
+	for i := range r.Pins {
+		if r.Pins[i].Identity == identity {
+			return &r.Pins[i]
+		}
+	}
+	return nil
+}
+
+// DependencyPolicyConfig controls the dependency checks run before
+// publishing.
+type DependencyPolicyConfig struct {
+	Enabled bool `json:"enabled"`
+	// Allow lists dependency identities that may use branch, revision or
+	// path requirements. Their pins are still checked.
+	Allow []string `json:"allow"`
+}
+
+// CheckDependencyPolicy returns the problems with the manifest's
+// dependencies: branch, revision or local path requirements, and, when
+// resolved is set, dependencies missing from Package.resolved or pinned
+// outside their requirement.
+func CheckDependencyPolicy(manifest *PackageManifest, resolved *PackageResolved, cfg DependencyPolicyConfig) []string {
+	var problems []string
+	for _, dep := range manifest.Dependencies {
+		name := dep.Identity
+		if name == "" {
+			name = identityFromLocation(dep.Location)
+		}
+		allowed := slices.ContainsFunc(cfg.Allow, func(a string) bool { return strings.EqualFold(a, name) })
+
+		local := dep.Kind == DependencyFileSystem || isLocalLocation(dep.Location)
+		if local && !allowed {
+			problems = append(problems, fmt.Sprintf("%s uses a local path (%s)", name, dep.Location))
+		}
+		if dep.Requirement != nil && !allowed &&
+			(dep.Requirement.Kind == RequirementBranch || dep.Requirement.Kind == RequirementRevision) {
+			problems = append(problems, fmt.Sprintf("%s uses a %s requirement (%s)", name, dep.Requirement.Kind, dep.Requirement.Value))
+		}
+
+		// File system dependencies are not pinned
+		if resolved == nil || dep.Kind == DependencyFileSystem || dep.Requirement == nil {
+			continue
+		}
+		pin := resolved.Pin(name)
+		if pin == nil {
+			problems = append(problems, fmt.Sprintf("Package.resolved is stale: %s is not pinned", name))
+			continue
+		}
+		if !pinSatisfies(*pin, *dep.Requirement) {
+			problems = append(problems, fmt.Sprintf("Package.resolved is stale: %s requires %s but is pinned at %s",
+				name, dep.Requirement, pin.describe()))
+		}
+	}
+	return problems
+}
+
+// isLocalLocation reports whether a source control location is a local
+// repository rather than a URL.
+func isLocalLocation(location string) bool {
+	return strings.HasPrefix(location, "/") || strings.HasPrefix(location, ".") ||
+		strings.HasPrefix(location, "~") || strings.HasPrefix(location, "file://")
+}
+
+// pinSatisfies reports whether a pin meets a requirement.
+func pinSatisfies(pin ResolvedPin, req Requirement) bool {
+	switch req.Kind {
+	case RequirementRange:
+		return pin.Version != "" &&
+			compareSemanticVersions(pin.Version, req.LowerBound) >= 0 &&
+			compareSemanticVersions(pin.Version, req.UpperBound) < 0
+	case RequirementExact:
+		return pin.Version != "" && compareSemanticVersions(pin.Version, req.Value) == 0
+	case RequirementBranch:
+		return pin.Branch == req.Value
+	case RequirementRevision:
+		return pin.Revision == req.Value
+	default:
+		return true
+	}
+}
+
+// describe formats the pinned state for messages.
+func (p ResolvedPin) describe() string {
+	switch {
+	case p.Version != "":
+		return p.Version
+	case p.Branch != "":
+		return "branch " + p.Branch
+	default:
+		return "revision " + p.Revision
+	}
+}
+
+// compareSemanticVersions orders two semantic versions, ignoring build
+// metadata. A prerelease sorts before its release.
+func compareSemanticVersions(a, b string) int {
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+	if c := compareDottedVersions(aCore, bCore); c != 0 {
+		return c
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	as, bs := strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < min(len(as), len(bs)); i++ {
+		x, xErr := strconv.Atoi(as[i])
+		y, yErr := strconv.Atoi(bs[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return x - y
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const resolvedV1 = `{
+  "object": {
+    "pins": [
+      {
+        "package": "swift-argument-parser",
+        "repositoryURL": "https://github.com/apple/swift-argument-parser.git",
+        "state": {
+          "branch": null,
+          "revision": "fddd1c00396eed152c45a46bea9f47b98e59301d",
+          "version": "1.2.0"
+        }
+      },
+      {
+        "package": "Yams",
+        "repositoryURL": "https://github.com/jpsim/Yams",
+        "state": {
+          "branch": "main",
+          "revision": "01835dc202670b5bb90d07f3eae41867e9ed29f6",
+          "version": null
+        }
+      }
+    ]
+  },
+  "version": 1
+}
+`
+
+const resolvedV2 = `{
+  "pins" : [
+    {
+      "identity" : "swift-argument-parser",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/apple/swift-argument-parser.git",
+      "state" : {
+        "revision" : "fddd1c00396eed152c45a46bea9f47b98e59301d",
+        "version" : "1.2.0"
+      }
+    },
+    {
+      "identity" : "yams",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/jpsim/Yams",
+      "state" : {
+        "branch" : "main",
+        "revision" : "01835dc202670b5bb90d07f3eae41867e9ed29f6"
+      }
+    }
+  ],
+  "version" : 2
+}
+`
+
+const resolvedV3 = `{
+  "originHash" : "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90",
+  "pins" : [
+    {
+      "identity" : "mona.linkedlist",
+      "kind" : "registry",
+      "location" : "",
+      "state" : {
+        "version" : "1.1.0"
+      }
+    }
+  ],
+  "version" : 3
+}
+`
+
+func TestParsePackageResolved(t *testing.T) {
+	argumentParser := ResolvedPin{
+		Identity: "swift-argument-parser",
+		Kind:     PinRemoteSourceControl,
+		Location: "https://github.com/apple/swift-argument-parser.git",
+		Version:  "1.2.0",
+		Revision: "fddd1c00396eed152c45a46bea9f47b98e59301d",
+	}
+	yams := ResolvedPin{
+		Identity: "yams",
+		Kind:     PinRemoteSourceControl,
+		Location: "https://github.com/jpsim/Yams",
+		Branch:   "main",
+		Revision: "01835dc202670b5bb90d07f3eae41867e9ed29f6",
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want PackageResolved
+	}{
+		{"v1", resolvedV1, PackageResolved{Version: 1, Pins: []ResolvedPin{argumentParser, yams}}},
+		{"v2", resolvedV2, PackageResolved{Version: 2, Pins: []ResolvedPin{argumentParser, yams}}},
+		{"v3", resolvedV3, PackageResolved{
+			Version:    3,
+			OriginHash: "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90",
+			Pins:       []ResolvedPin{{Identity: "mona.linkedlist", Kind: PinRegistry, Version: "1.1.0"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePackageResolved([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParsePackageResolved() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParsePackageResolved() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParsePackageResolved([]byte(`{"version": 4, "pins": []}`)); err == nil || !strings.Contains(err.Error(), "unsupported version 4") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestLoadPackageResolved(t *testing.T) {
+	dir := t.TempDir()
+	resolved, err := LoadPackageResolved(dir)
+	if err != nil || resolved != nil {
+		t.Fatalf("LoadPackageResolved() without a file = %v, %v", resolved, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Package.resolved"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPackageResolved(dir); err == nil || !strings.Contains(err.Error(), "failed to parse Package.resolved") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestCheckDependencyPolicy(t *testing.T) {
+	resolved, err := ParsePackageResolved([]byte(resolvedV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	argumentParser := Dependency{
+		Kind:        DependencySourceControl,
+		Identity:    "swift-argument-parser",
+		Location:    "https://github.com/apple/swift-argument-parser.git",
+		Requirement: &Requirement{Kind: RequirementRange, LowerBound: "1.2.0", UpperBound: "2.0.0"},
+	}
+	yams := Dependency{
+		Kind:        DependencySourceControl,
+		Identity:    "yams",
+		Location:    "https://github.com/jpsim/Yams",
+		Requirement: &Requirement{Kind: RequirementBranch, Value: "main"},
+	}
+
+	tests := []struct {
+		name     string
+		deps     []Dependency
+		resolved *PackageResolved
+		allow    []string
+		want     []string
+	}{
+		{"stable", []Dependency{argumentParser}, resolved, nil, nil},
+		{"branch", []Dependency{argumentParser, yams}, resolved, nil, []string{"yams uses a branch requirement (main)"}},
+		{"allowed branch", []Dependency{yams}, resolved, []string{"Yams"}, nil},
+		{
+			name: "revision",
+			deps: []Dependency{{Kind: DependencySourceControl, Identity: "yams", Location: "https://github.com/jpsim/Yams",
+				Requirement: &Requirement{Kind: RequirementRevision, Value: "01835dc202670b5bb90d07f3eae41867e9ed29f6"}}},
+			want: []string{"yams uses a revision requirement (01835dc202670b5bb90d07f3eae41867e9ed29f6)"},
+		},
+		{
+			name: "local paths",
+			deps: []Dependency{
+				{Kind: DependencyFileSystem, Identity: "core", Location: "../Core"},
+				{Kind: DependencySourceControl, Identity: "tools", Location: "/src/tools",
+					Requirement: &Requirement{Kind: RequirementExact, Value: "1.0.0"}},
+			},
+			want: []string{"core uses a local path (../Core)", "tools uses a local path (/src/tools)"},
+		},
+		{
+			name:     "pinned outside range",
+			deps:     []Dependency{{Kind: DependencySourceControl, Identity: "swift-argument-parser", Requirement: &Requirement{Kind: RequirementRange, LowerBound: "1.3.0", UpperBound: "2.0.0"}}},
+			resolved: resolved,
+			want:     []string{"Package.resolved is stale: swift-argument-parser requires 1.3.0..<2.0.0 but is pinned at 1.2.0"},
+		},
+		{
+			name:     "pinned to another branch",
+			deps:     []Dependency{{Kind: DependencySourceControl, Identity: "yams", Requirement: &Requirement{Kind: RequirementBranch, Value: "develop"}}},
+			resolved: resolved,
+			allow:    []string{"yams"},
+			want:     []string{"Package.resolved is stale: yams requires branch: develop but is pinned at branch main"},
+		},
+		{
+			name:     "not pinned",
+			deps:     []Dependency{{Kind: DependencyRegistry, Identity: "mona.LinkedList", Requirement: &Requirement{Kind: RequirementExact, Value: "1.1.0"}}},
+			resolved: resolved,
+			want:     []string{"Package.resolved is stale: mona.LinkedList is not pinned"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := &PackageManifest{Name: "Kit", Dependencies: tt.deps}
+			got := CheckDependencyPolicy(manifest, tt.resolved, DependencyPolicyConfig{Enabled: true, Allow: tt.allow})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckDependencyPolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSemanticVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-beta.1", "1.0.0", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.10", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.1", 1},
+		{"1.0.0+build.1", "1.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		got := compareSemanticVersions(tt.a, tt.b)
+		if (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+			t.Errorf("compareSemanticVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
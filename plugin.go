@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
@@ -19,21 +20,33 @@ var Version = "0.1.0"
 
 // Config represents Swift PM plugin configuration.
 type Config struct {
-	Registry        string        `json:"registry"`
-	Scope           string        `json:"scope"`
-	Token           string        `json:"token"`
-	PackageName     string        `json:"package_name"`
-	ManifestPath    string        `json:"manifest_path"`
-	UpdateManifest  bool          `json:"update_manifest"`
-	VersionConstant string        `json:"version_constant"`
-	CreateTag       bool          `json:"create_tag"`
-	TagPrefix       string        `json:"tag_prefix"`
-	Validate        bool          `json:"validate"`
-	Build           bool          `json:"build"`
-	Test            bool          `json:"test"`
-	TestConfig      TestConfig    `json:"test_config"`
-	Archive         ArchiveConfig `json:"archive"`
-	DryRun          bool          `json:"dry_run"`
+	Registry              string                      `json:"registry"`
+	Scope                 string                      `json:"scope"`
+	Token                 string                      `json:"token"`
+	PackageName           string                      `json:"package_name"`
+	ManifestPath          string                      `json:"manifest_path"`
+	UpdateManifest        bool                        `json:"update_manifest"`
+	VersionConstant       string                      `json:"version_constant"`
+	CreateTag             bool                        `json:"create_tag"`
+	TagPrefix             string                      `json:"tag_prefix"`
+	Validate              bool                        `json:"validate"`
+	Build                 bool                        `json:"build"`
+	Test                  bool                        `json:"test"`
+	TestConfig            TestConfig                  `json:"test_config"`
+	Archive               ArchiveConfig               `json:"archive"`
+	SecretScan            SecretScanConfig            `json:"secret_scan"`
+	BinaryTargets         []BinaryTargetConfig        `json:"binary_targets"`
+	VersionFiles          []VersionFileConfig         `json:"version_files"`
+	VersionSource         VersionSourceConfig         `json:"version_source"`
+	MarketingVersion      MarketingVersionConfig      `json:"marketing_version"`
+	Podspec               PodspecConfig               `json:"podspec"`
+	DependencyPolicy      DependencyPolicyConfig      `json:"dependency_policy"`
+	Lint                  LintConfig                  `json:"lint"`
+	RegistryDependencies  RegistryDependenciesConfig  `json:"registry_dependencies"`
+	APIBreakage           APIBreakageConfig           `json:"api_breakage"`
+	ManifestCompatibility ManifestCompatibilityConfig `json:"manifest_compatibility"`
+	LogDir                string                      `json:"log_dir"`
+	DryRun                bool                        `json:"dry_run"`
 }
 
 // TestConfig defines test execution options.
@@ -41,12 +54,39 @@ type TestConfig struct {
 	Configuration string `json:"configuration"`
 	Coverage      bool   `json:"coverage"`
 	Parallel      bool   `json:"parallel"`
+	// ReportPath receives a JUnit XML report of the results when set.
+	ReportPath string `json:"report_path"`
+	// MaxFailures is the number of failed tests listed in the message.
+	MaxFailures int `json:"max_failures"`
 }
 
 // ArchiveConfig defines archive creation options.
 type ArchiveConfig struct {
-	IncludeDocs bool     `json:"include_docs"`
-	Exclude     []string `json:"exclude"`
+	IncludeDocs      bool     `json:"include_docs"`
+	Exclude          []string `json:"exclude"`
+	Validate         bool     `json:"validate"`
+	PreserveSymlinks bool     `json:"preserve_symlinks"`
+	MaxFileSize      int64    `json:"max_file_size"`
+	MaxArchiveSize   int64    `json:"max_archive_size"`
+	OutputDir        string   `json:"output_dir"`
+	Workers          int      `json:"workers"`
+	Formats          []string `json:"formats"`
+	Digests          []string `json:"digests"`
+	// Substitutes maps archive entry names to files archived in their
+	// place, such as a rewritten Package.swift.
+	Substitutes map[string]string `json:"-"`
+}
+
+// Default archive size limits.
+const (
+	defaultMaxFileSize    = 100 << 20
+	defaultMaxArchiveSize = 512 << 20
+)
+
+// SecretScanConfig defines credential scanning of archive contents.
+type SecretScanConfig struct {
+	Enabled       bool   `json:"enabled"`
+	AllowlistFile string `json:"allowlist_file"`
 }
 
 // SwiftPMPlugin implements the Swift Package Manager plugin.
@@ -70,9 +110,12 @@ func (p *SwiftPMPlugin) Validate(ctx context.Context, config map[string]any) (*p
 	cfg := p.parseConfig(config)
 	vb := helpers.NewValidationBuilder()
 
-	// Check Swift installation
-	if _, err := exec.LookPath("swift"); err != nil {
-		vb.AddError("swift", "Swift CLI not found in PATH")
+	// Validation, build and test steps need the Swift toolchain; the
+	// manifest can otherwise be read statically
+	if cfg.Validate || cfg.Build || cfg.Test {
+		if _, err := exec.LookPath("swift"); err != nil {
+			vb.AddError("swift", "Swift CLI not found in PATH (required by validate, build and test)")
+		}
 	}
 
 	// Check scope
@@ -92,6 +135,58 @@ func (p *SwiftPMPlugin) Validate(ctx context.Context, config map[string]any) (*p
 		}
 	}
 
+	// Check archive formats; the zip is always built for the registry
+	for _, format := range cfg.Archive.Formats {
+		if !isArchiveFormat(format) {
+			vb.AddError("archive.formats", fmt.Sprintf("Unsupported archive format: %s (supported: %s)",
+				format, strings.Join(archiveFormats, ", ")))
+		}
+	}
+
+	// Check additional archive digests
+	for _, algorithm := range cfg.Archive.Digests {
+		if !isDigestAlgorithm(algorithm) {
+			vb.AddError("archive.digests", fmt.Sprintf("Unsupported digest algorithm: %s (supported: %s)",
+				algorithm, strings.Join(digestAlgorithms, ", ")))
+		}
+	}
+
+	if cfg.TestConfig.MaxFailures < 1 {
+		vb.AddError("test_config.max_failures", "test_config.max_failures must be at least 1")
+	}
+
+	// Check binary targets
+	for i, target := range cfg.BinaryTargets {
+		if target.Name == "" || target.Path == "" || target.URL == "" {
+			vb.AddError("binary_targets", fmt.Sprintf("binary_targets[%d] requires name, path and url", i))
+		}
+	}
+
+	// Check version files
+	for i, file := range cfg.VersionFiles {
+		if problem := file.validate(); problem != "" {
+			vb.AddError("version_files", fmt.Sprintf("version_files[%d] %s", i, problem))
+		}
+	}
+	if problem := cfg.VersionSource.validate(); problem != "" {
+		vb.AddError("version_source", fmt.Sprintf("version_source %s", problem))
+	}
+	if problem := cfg.MarketingVersion.validate(); problem != "" {
+		vb.AddError("marketing_version", fmt.Sprintf("marketing_version %s", problem))
+	}
+	if problem := cfg.Podspec.validate(); problem != "" {
+		vb.AddError("podspec", fmt.Sprintf("podspec %s", problem))
+	}
+	if problem := cfg.Lint.validate(); problem != "" {
+		vb.AddError("lint", fmt.Sprintf("lint %s", problem))
+	}
+	if problem := cfg.RegistryDependencies.validate(); problem != "" {
+		vb.AddError("registry_dependencies", fmt.Sprintf("registry_dependencies %s", problem))
+	}
+	if problem := cfg.ManifestCompatibility.validate(); problem != "" {
+		vb.AddError("manifest_compatibility", fmt.Sprintf("manifest_compatibility %s", problem))
+	}
+
 	// Check manifest exists
 	manifestPath := cfg.ManifestPath
 	if manifestPath == "" {
@@ -142,7 +237,93 @@ func (p *SwiftPMPlugin) executePrePublish(ctx context.Context, releaseCtx *plugi
 		}
 	}
 
-	swift := NewSwiftCLI(workDir)
+	// Package.swift and its Package@swift-X.swift variants
+	manifests, err := FindManifestVariants(manifestPath)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to read manifests: %v", err),
+		}, nil
+	}
+	for _, manifest := range manifests {
+		logger.Info("Found manifest", "file", manifest.Name, "tools_version", manifest.ToolsVersion)
+	}
+
+	// Package.swift is evaluated once, by the first step that needs it
+	var packageManifest *PackageManifest
+	loadManifest := func() (*PackageManifest, error) {
+		if packageManifest != nil {
+			return packageManifest, nil
+		}
+		manifest, warnings, err := LoadManifest(ctx, workDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range warnings {
+			logger.Warn("Package.swift read statically", "warning", warning)
+		}
+		packageManifest = manifest
+		return manifest, nil
+	}
+
+	// Reject unstable dependencies and a stale Package.resolved
+	if cfg.DependencyPolicy.Enabled {
+		manifest, err := loadManifest()
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to parse Package.swift: %v", err),
+			}, nil
+		}
+		resolved, err := LoadPackageResolved(workDir)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+		if resolved == nil {
+			logger.Info("No Package.resolved found; skipping pin checks")
+		}
+		if problems := CheckDependencyPolicy(manifest, resolved, cfg.DependencyPolicy); len(problems) > 0 {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Dependency policy check failed: %s", strings.Join(problems, "; ")),
+			}, nil
+		}
+		logger.Info("Dependencies checked", "dependencies", len(manifest.Dependencies))
+	}
+
+	// Lint Package.swift
+	var lintFindings []LintFinding
+	if cfg.Lint.Enabled {
+		manifest, err := loadManifest()
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to parse Package.swift: %v", err),
+			}, nil
+		}
+		lintFindings = LintManifest(manifest, cfg.Lint)
+		var lintErrors []string
+		for _, finding := range lintFindings {
+			if finding.Severity == SeverityError {
+				lintErrors = append(lintErrors, fmt.Sprintf("%s: %s", finding.Rule, finding.Message))
+			} else {
+				logger.Warn("Package.swift lint warning", "rule", finding.Rule, "message", finding.Message)
+			}
+		}
+		if len(lintErrors) > 0 {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Package.swift lint failed: %s", strings.Join(lintErrors, "; ")),
+				Outputs: map[string]any{"lint_findings": lintFindings},
+			}, nil
+		}
+	}
+
+	// Stream swift output to the hook logger, and to log_dir when set
+	swift := NewSwiftCLI(workDir, logger, resolvePath(workDir, cfg.LogDir))
 
 	// Validate package
 	if cfg.Validate {
@@ -175,12 +356,39 @@ func (p *SwiftPMPlugin) executePrePublish(ctx context.Context, releaseCtx *plugi
 	}
 
 	// Run tests
+	var testSummary *TestSummary
 	if cfg.Test {
 		logger.Info("Running tests")
 		if cfg.DryRun {
 			logger.Info("[DRY-RUN] Would run tests", "config", cfg.TestConfig)
 		} else {
-			if err := swift.Test(ctx, cfg.TestConfig); err != nil {
+			testSummary, err = swift.Test(ctx, cfg.TestConfig)
+			if testSummary != nil {
+				logger.Info("Test results",
+					"tests", testSummary.Tests,
+					"passed", testSummary.Passed,
+					"failed", testSummary.Failed,
+					"skipped", testSummary.Skipped,
+					"duration", testSummary.Duration)
+				if reportPath := resolvePath(workDir, cfg.TestConfig.ReportPath); reportPath != "" {
+					if err := WriteJUnitReport(reportPath, testSummary); err != nil {
+						return &plugin.ExecuteResponse{
+							Success: false,
+							Message: fmt.Sprintf("Failed to write test report: %v", err),
+						}, nil
+					}
+					logger.Info("Wrote JUnit test report", "path", reportPath)
+				}
+			}
+			if err != nil {
+				// List the failed tests rather than the raw output
+				if testSummary != nil && testSummary.Failed > 0 {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Tests failed: %s", testSummary.FailureSummary(cfg.TestConfig.MaxFailures)),
+						Outputs: map[string]any{"tests": testSummary},
+					}, nil
+				}
 				return &plugin.ExecuteResponse{
 					Success: false,
 					Message: fmt.Sprintf("Tests failed: %v", err),
@@ -189,15 +397,149 @@ func (p *SwiftPMPlugin) executePrePublish(ctx context.Context, releaseCtx *plugi
 		}
 	}
 
-	// Update version constant in manifest
+	// Reject API breaking changes in releases below a major version
+	var apiBaseline string
+	var apiBreakages []APIBreakage
+	if cfg.APIBreakage.Enabled {
+		apiBaseline, err = previousReleaseTag(ctx, workDir, cfg.TagPrefix, version)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to find the previous release: %v", err),
+			}, nil
+		}
+		switch {
+		case apiBaseline == "":
+			logger.Info("No previous release tag found; skipping API breakage check")
+		case isMajorRelease(releaseCtx, strings.TrimPrefix(apiBaseline, cfg.TagPrefix)):
+			logger.Info("Major release; skipping API breakage check", "baseline", apiBaseline)
+			apiBaseline = ""
+		case cfg.DryRun:
+			logger.Info("[DRY-RUN] Would diagnose API breaking changes", "baseline", apiBaseline)
+		default:
+			logger.Info("Diagnosing API breaking changes", "baseline", apiBaseline)
+			report, err := swift.DiagnoseAPIBreakingChanges(ctx, apiBaseline)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("API breakage check failed: %v", err),
+				}, nil
+			}
+			apiBreakages = ParseAPIBreakages(report)
+
+			var manifest *PackageManifest
+			if len(apiBreakages) > 0 && len(cfg.APIBreakage.Allow) > 0 {
+				manifest, err = loadManifest()
+				if err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Failed to parse Package.swift: %v", err),
+					}, nil
+				}
+			}
+			if rejected := CheckAPIBreakages(apiBreakages, manifest, cfg.APIBreakage); len(rejected) > 0 {
+				problems := make([]string, len(rejected))
+				for i, b := range rejected {
+					problems[i] = fmt.Sprintf("%s: %s", b.Module, b.Description)
+				}
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("API breaking changes since %s need a major release: %s",
+						apiBaseline, strings.Join(problems, "; ")),
+				}, nil
+			}
+			for _, b := range apiBreakages {
+				logger.Warn("Allowed API breakage", "module", b.Module, "breakage", b.Description)
+			}
+		}
+	}
+
+	// Compare Package.swift with the previous release's manifest
+	var manifestChanges []ManifestChange
+	if cfg.ManifestCompatibility.Enabled {
+		previousSource, baseline, err := previousReleaseManifest(ctx, workDir, cfg, releaseCtx, loadManifest)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to read the previous release's manifest: %v", err),
+			}, nil
+		}
+		if baseline == "" {
+			logger.Info("No previous release found; skipping manifest compatibility check")
+		} else {
+			previous, previousWarnings, err := readManifestSource(ctx, previousSource, workDir)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to parse Package.swift of %s: %v", baseline, err),
+				}, nil
+			}
+			current, currentWarnings, err := readManifest(ctx, workDir)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to parse Package.swift: %v", err),
+				}, nil
+			}
+			var partial []string
+			for _, warning := range previousWarnings {
+				logger.Warn("Package.swift read statically", "baseline", baseline, "warning", warning)
+				partial = append(partial, fmt.Sprintf("%s: %s", baseline, warning))
+			}
+			for _, warning := range currentWarnings {
+				logger.Warn("Package.swift read statically", "warning", warning)
+				partial = append(partial, warning)
+			}
+
+			// Computed parts left out of either manifest would show up as
+			// removed, so partially read manifests are not compared
+			if len(partial) > 0 {
+				if !cfg.ManifestCompatibility.AllowPartial {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Package.swift could not be read completely to compare with %s "+
+							"(install the Swift toolchain or set manifest_compatibility.allow_partial): %s",
+							baseline, strings.Join(partial, "; ")),
+					}, nil
+				}
+				logger.Warn("Package.swift could not be read completely; skipping manifest compatibility check", "baseline", baseline)
+			} else {
+				manifestChanges = DiffManifests(previous, current)
+				for _, change := range manifestChanges {
+					if change.Breaking {
+						logger.Warn("Breaking manifest change", "baseline", baseline, "change", change.Description)
+					} else {
+						logger.Info("Manifest change", "baseline", baseline, "change", change.Description)
+					}
+				}
+			}
+			// baseline is the previous tag, or a version from the registry
+			if !isMajorRelease(releaseCtx, strings.TrimPrefix(baseline, cfg.TagPrefix)) {
+				if rejected := CheckManifestChanges(manifestChanges, cfg.ManifestCompatibility); len(rejected) > 0 {
+					problems := make([]string, len(rejected))
+					for i, change := range rejected {
+						problems[i] = change.Description
+					}
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Package.swift changes since %s need a major release: %s",
+							baseline, strings.Join(problems, "; ")),
+					}, nil
+				}
+			}
+		}
+	}
+
+	// Update version constant in every manifest
 	if cfg.UpdateManifest && cfg.VersionConstant != "" {
-		logger.Info("Updating version in Package.swift", "constant", cfg.VersionConstant)
+		logger.Info("Updating version in manifests", "constant", cfg.VersionConstant, "manifests", len(manifests))
 		if cfg.DryRun {
 			logger.Info("[DRY-RUN] Would update version constant",
 				"constant", cfg.VersionConstant,
-				"version", version)
+				"version", version,
+				"manifests", manifestVariantNames(manifests))
 		} else {
-			if err := UpdateVersionConstant(manifestPath, cfg.VersionConstant, version); err != nil {
+			if err := UpdateVersionConstants(manifests, cfg.VersionConstant, version); err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
 					Message: fmt.Sprintf("Failed to update version: %v", err),
@@ -206,10 +548,167 @@ func (p *SwiftPMPlugin) executePrePublish(ctx context.Context, releaseCtx *plugi
 		}
 	}
 
+	// Generate the version source file
+	var versionSource *GeneratedFile
+	if cfg.VersionSource.Directory != "" {
+		logger.Info("Generating version source", "directory", cfg.VersionSource.Directory)
+		versionSource, err = generateVersionSource(ctx, workDir, cfg, releaseCtx)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to generate version source: %v", err),
+			}, nil
+		}
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would generate version source", "path", versionSource.Path, "commit", versionSource.Commit, "date", versionSource.Date)
+			logger.Debug("[DRY-RUN] Version source content", "content", versionSource.Content)
+		} else {
+			logger.Info("Generated version source", "path", versionSource.Path, "commit", versionSource.Commit, "date", versionSource.Date)
+		}
+	}
+
+	// Stamp the version into configured source files
+	var versionReplacements []VersionReplacement
+	if len(cfg.VersionFiles) > 0 {
+		logger.Info("Updating version files", "entries", len(cfg.VersionFiles))
+		versionReplacements, err = StampVersionFiles(workDir, cfg.VersionFiles, newTemplateData(version, cfg.TagPrefix), !cfg.DryRun)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to update version files: %v", err),
+			}, nil
+		}
+		for _, r := range versionReplacements {
+			msg := "Updated version file"
+			if cfg.DryRun {
+				msg = "[DRY-RUN] Would update version file"
+			}
+			logger.Info(msg, "file", r.File, "line", r.Line, "name", r.Name, "old", r.Old, "new", r.New)
+		}
+	}
+
+	// Update Xcode project and Info.plist marketing versions
+	var marketingVersions []VersionReplacement
+	if len(cfg.MarketingVersion.Paths) > 0 {
+		logger.Info("Updating marketing versions", "paths", len(cfg.MarketingVersion.Paths))
+		marketingVersions, err = UpdateMarketingVersions(workDir, cfg.MarketingVersion, newTemplateData(version, cfg.TagPrefix), !cfg.DryRun)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to update marketing versions: %v", err),
+			}, nil
+		}
+		for _, r := range marketingVersions {
+			msg := "Updated marketing version"
+			if cfg.DryRun {
+				msg = "[DRY-RUN] Would update marketing version"
+			}
+			logger.Info(msg, "file", r.File, "line", r.Line, "name", r.Name, "old", r.Old, "new", r.New)
+		}
+	}
+
+	// Update and check companion CocoaPods podspecs
+	var podspecs []Podspec
+	if cfg.Podspec.Enabled {
+		var manifest *PackageManifest
+		if cfg.Podspec.Check {
+			manifest, err = loadManifest()
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to parse Package.swift: %v", err),
+				}, nil
+			}
+		}
+
+		logger.Info("Updating podspecs")
+		podspecs, err = UpdatePodspecs(workDir, cfg.Podspec, newTemplateData(version, cfg.TagPrefix), manifest, !cfg.DryRun)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to update podspecs: %v", err),
+			}, nil
+		}
+		for _, spec := range podspecs {
+			for _, r := range spec.Replacements {
+				msg := "Updated podspec"
+				if cfg.DryRun {
+					msg = "[DRY-RUN] Would update podspec"
+				}
+				logger.Info(msg, "file", r.File, "line", r.Line, "name", r.Name, "old", r.Old, "new", r.New)
+			}
+		}
+	}
+
+	// Update binary target urls and checksums in every manifest
+	var binaryTargets []BinaryTargetUpdate
+	if len(cfg.BinaryTargets) > 0 {
+		logger.Info("Computing binary target checksums", "targets", len(cfg.BinaryTargets))
+		var err error
+		binaryTargets, err = ResolveBinaryTargets(workDir, cfg.BinaryTargets, newTemplateData(version, cfg.TagPrefix))
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to resolve binary targets: %v", err),
+			}, nil
+		}
+
+		if cfg.DryRun {
+			for _, target := range binaryTargets {
+				logger.Info("[DRY-RUN] Would update binary target",
+					"target", target.Name,
+					"url", target.URL,
+					"checksum", target.Checksum,
+					"manifests", manifestVariantNames(manifests))
+			}
+		} else {
+			if err := UpdateBinaryTargets(manifests, binaryTargets); err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to update binary targets: %v", err),
+				}, nil
+			}
+		}
+	}
+
+	outputs := map[string]any{"manifests": manifests}
+	if testSummary != nil {
+		outputs["tests"] = testSummary
+	}
+	if len(lintFindings) > 0 {
+		outputs["lint_findings"] = lintFindings
+	}
+	if apiBaseline != "" {
+		outputs["api_baseline"] = apiBaseline
+	}
+	if len(apiBreakages) > 0 {
+		outputs["api_breakages"] = apiBreakages
+	}
+	if len(manifestChanges) > 0 {
+		outputs["manifest_changes"] = manifestChanges
+	}
+	if versionSource != nil {
+		outputs["version_source"] = versionSource
+	}
+	if len(versionReplacements) > 0 {
+		outputs["version_files"] = versionReplacements
+	}
+	if len(marketingVersions) > 0 {
+		outputs["marketing_versions"] = marketingVersions
+	}
+	if len(podspecs) > 0 {
+		outputs["podspecs"] = podspecs
+	}
+	if len(binaryTargets) > 0 {
+		outputs["binary_targets"] = binaryTargets
+	}
+
 	logger.Info("PrePublish completed successfully")
 	return &plugin.ExecuteResponse{
-		Success: true,
-		Message: "Package validated and built successfully",
+		Success:   true,
+		Message:   "Package validated and built successfully",
+		Outputs:   outputs,
+		Artifacts: binaryTargetArtifacts(binaryTargets),
 	}, nil
 }
 
@@ -235,39 +734,221 @@ func (p *SwiftPMPlugin) executePostPublish(ctx context.Context, releaseCtx *plug
 	// Parse manifest to get package name
 	packageName := cfg.PackageName
 	if packageName == "" {
-		manifest, err := ParseManifest(ctx, workDir)
+		manifest, warnings, err := LoadManifest(ctx, workDir)
 		if err != nil {
 			return &plugin.ExecuteResponse{
 				Success: false,
 				Message: fmt.Sprintf("Failed to parse Package.swift: %v", err),
 			}, nil
 		}
+		for _, warning := range warnings {
+			logger.Warn("Package.swift read statically", "warning", warning)
+		}
 		packageName = manifest.Name
 	}
 
 	logger = logger.With("package", packageName, "scope", cfg.Scope)
 
-	// Create package archive
+	// Never archive previously saved release outputs, swift logs or test
+	// reports
+	outputDir := resolvePath(workDir, cfg.Archive.OutputDir)
+	for _, dir := range []string{outputDir, resolvePath(workDir, cfg.LogDir)} {
+		if dir == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(workDir, dir); err == nil && filepath.IsLocal(rel) {
+			cfg.Archive.Exclude = append(cfg.Archive.Exclude, rel+string(filepath.Separator))
+		}
+	}
+
+	if report := resolvePath(workDir, cfg.TestConfig.ReportPath); report != "" {
+		if rel, err := filepath.Rel(workDir, report); err == nil && filepath.IsLocal(rel) {
+			cfg.Archive.Exclude = append(cfg.Archive.Exclude, rel)
+		}
+	}
+
+	// Binary target artifacts are downloaded from their url, not shipped in
+	// the source archive
+	for _, target := range cfg.BinaryTargets {
+		artifactPath, err := renderTemplate("binary_targets.path", target.Path, newTemplateData(version, cfg.TagPrefix))
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(artifactPath) {
+			artifactPath = filepath.Join(workDir, artifactPath)
+		}
+		if rel, err := filepath.Rel(workDir, artifactPath); err == nil && filepath.IsLocal(rel) {
+			cfg.Archive.Exclude = append(cfg.Archive.Exclude, rel)
+		}
+	}
+
+	// Archive Package.swift and its variants with registry dependencies,
+	// leaving the working tree untouched
+	var registryRewrites []RegistryDependencyRewrite
+	var manifestDiff string
+	if cfg.RegistryDependencies.Enabled {
+		manifests, err := FindManifestVariants(manifestPath)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to read manifests: %v", err),
+			}, nil
+		}
+		var lookup identifierLookup
+		if cfg.RegistryDependencies.Lookup {
+			// Variants usually share dependencies, so each URL is looked up once
+			client := NewRegistryClient(cfg.Registry, cfg.Token)
+			identifiers := make(map[string][]string)
+			lookup = func(ctx context.Context, repositoryURL string) ([]string, error) {
+				if ids, ok := identifiers[repositoryURL]; ok {
+					return ids, nil
+				}
+				ids, err := client.LookupIdentifiers(ctx, repositoryURL)
+				if err == nil {
+					identifiers[repositoryURL] = ids
+				}
+				return ids, err
+			}
+		}
+
+		var diffs []string
+		for _, manifest := range manifests {
+			content, err := os.ReadFile(manifest.Path)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to read %s: %v", manifest.Name, err),
+				}, nil
+			}
+			rewritten, rewrites, err := RewriteRegistryDependencies(ctx, content, cfg.RegistryDependencies, lookup)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to rewrite registry dependencies in %s: %v", manifest.Name, err),
+				}, nil
+			}
+			for i, r := range rewrites {
+				rewrites[i].Manifest = manifest.Name
+				logger.Info("Archiving dependency as registry package", "manifest", manifest.Name, "line", r.Line, "url", r.URL, "id", r.ID)
+			}
+			registryRewrites = append(registryRewrites, rewrites...)
+			if len(rewrites) == 0 {
+				continue
+			}
+
+			substitute, err := writeSubstitute(manifest.Path, rewritten)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to rewrite registry dependencies in %s: %v", manifest.Name, err),
+				}, nil
+			}
+			defer func() { _ = os.Remove(substitute) }()
+			if cfg.Archive.Substitutes == nil {
+				cfg.Archive.Substitutes = make(map[string]string)
+			}
+			cfg.Archive.Substitutes[manifest.Name] = substitute
+			diffs = append(diffs, unifiedDiff(manifest.Name, content, rewritten))
+		}
+
+		manifestDiff = strings.Join(diffs, "")
+		if cfg.DryRun && manifestDiff != "" {
+			logger.Info("[DRY-RUN] Archived manifests differ from the working tree", "diff", manifestDiff)
+		}
+	}
+
+	// Scan archive contents for credentials
+	if cfg.SecretScan.Enabled {
+		logger.Info("Scanning archive contents for secrets")
+		allowlistPath := cfg.SecretScan.AllowlistFile
+		if allowlistPath != "" && !filepath.IsAbs(allowlistPath) {
+			allowlistPath = filepath.Join(workDir, allowlistPath)
+		}
+		findings, err := ScanArchiveSecrets(ctx, workDir, cfg.Archive, allowlistPath)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Secret scan failed: %v", err),
+			}, nil
+		}
+		if len(findings) > 0 {
+			lines := make([]string, 0, len(findings))
+			for _, f := range findings {
+				lines = append(lines, "  "+f.String())
+			}
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Secret scan found %d potential secret(s) in archive contents:\n%s",
+					len(findings), strings.Join(lines, "\n")),
+				Outputs: map[string]any{"secret_findings": findings},
+			}, nil
+		}
+	}
+
+	// Create package archive. This also runs in dry-run mode so the preview
+	// reflects exactly what would be published.
 	logger.Info("Creating package archive")
-	var archivePath, checksum string
-	var err error
+	report, err := CreateArchive(ctx, workDir, version, cfg.Archive)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create archive: %v", err),
+		}, nil
+	}
+	defer report.Remove()
 
+	logger.Info("Archive created",
+		"checksum", report.Checksum,
+		"files", report.FileCount,
+		"size", report.Size,
+		"total_size", report.TotalSize)
+	for _, archive := range report.Archives {
+		logger.Info("Source archive created", "format", archive.Format, "checksum", archive.Checksum, "size", archive.Size)
+	}
+	for _, entry := range report.Largest {
+		logger.Debug("Large archive entry", "path", entry.Path, "size", entry.Size)
+	}
 	if cfg.DryRun {
-		logger.Info("[DRY-RUN] Would create archive", "exclude", cfg.Archive.Exclude)
-		archivePath = "/tmp/dry-run-archive.zip"
-		checksum = "dry-run-checksum"
-	} else {
-		archivePath, checksum, err = CreateArchive(workDir, version, cfg.Archive)
+		for _, entry := range report.Entries {
+			logger.Info("[DRY-RUN] Archive entry", "path", entry.Path, "size", entry.Size)
+		}
+	}
+
+	// Validate archive contents before anything is uploaded
+	var archivedManifests []ManifestVariant
+	if cfg.Archive.Validate {
+		logger.Info("Validating package archive")
+		validation, err := ValidateArchive(ctx, report.Path)
+		if validation != nil {
+			for _, warning := range validation.Warnings {
+				logger.Warn("Archived Package.swift read statically", "warning", warning)
+			}
+		}
+		if err == nil {
+			err = checkArchivedManifests(manifestPath, validation.Manifests)
+		}
 		if err != nil {
 			return &plugin.ExecuteResponse{
 				Success: false,
-				Message: fmt.Sprintf("Failed to create archive: %v", err),
+				Message: fmt.Sprintf("Archive validation failed: %v", err),
 			}, nil
 		}
-		defer func() { _ = os.Remove(archivePath) }()
+		archivedManifests = validation.Manifests
+		for _, manifest := range archivedManifests {
+			logger.Info("Archived manifest", "file", manifest.Name, "tools_version", manifest.ToolsVersion)
+		}
 	}
 
-	logger.Info("Archive created", "checksum", checksum)
+	// Keep the archives, checksum files and report as release artifacts
+	if outputDir != "" {
+		if err := report.SaveTo(outputDir, ArchiveBaseName(cfg.Scope, packageName, version)); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to save archive: %v", err),
+			}, nil
+		}
+		logger.Info("Archive saved", "path", report.Path, "checksum_file", report.ChecksumPath, "report", report.ReportPath)
+	}
 
 	// Publish to registry
 	if cfg.Registry != "" {
@@ -281,7 +962,7 @@ func (p *SwiftPMPlugin) executePostPublish(ctx context.Context, releaseCtx *plug
 				"version", version)
 		} else {
 			client := NewRegistryClient(cfg.Registry, cfg.Token)
-			if err := client.Publish(ctx, cfg.Scope, packageName, version, archivePath, checksum); err != nil {
+			if err := client.Publish(ctx, cfg.Scope, packageName, version, report.Path, report.Digests); err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
 					Message: fmt.Sprintf("Failed to publish to registry: %v", err),
@@ -309,15 +990,33 @@ func (p *SwiftPMPlugin) executePostPublish(ctx context.Context, releaseCtx *plug
 
 	var msg string
 	if cfg.DryRun {
-		msg = fmt.Sprintf("[DRY-RUN] Would publish %s@%s to registry", packageName, version)
+		msg = fmt.Sprintf("[DRY-RUN] Would publish %s@%s to registry (%s)", packageName, version, report.Summary())
 	} else {
-		msg = fmt.Sprintf("Published %s@%s to registry", packageName, version)
+		msg = fmt.Sprintf("Published %s@%s to registry (%s)", packageName, version, report.Summary())
+	}
+
+	outputs := map[string]any{"archive": report.Outputs()}
+	if len(archivedManifests) > 0 {
+		outputs["manifests"] = archivedManifests
+	}
+	if len(registryRewrites) > 0 {
+		outputs["registry_dependencies"] = registryRewrites
+		outputs["manifest_diff"] = manifestDiff
+	}
+	if cfg.DryRun {
+		files := make([]string, 0, len(report.Entries))
+		for _, entry := range report.Entries {
+			files = append(files, entry.Path)
+		}
+		outputs["archive_files"] = files
 	}
 
 	logger.Info("PostPublish completed successfully")
 	return &plugin.ExecuteResponse{
-		Success: true,
-		Message: msg,
+		Success:   true,
+		Message:   msg,
+		Outputs:   outputs,
+		Artifacts: report.Artifacts(),
 	}, nil
 }
 
@@ -346,6 +1045,7 @@ func (p *SwiftPMPlugin) parseConfig(raw map[string]any) *Config {
 		Configuration: "debug",
 		Coverage:      false,
 		Parallel:      true,
+		MaxFailures:   5,
 	}
 	if testRaw, ok := raw["test_config"].(map[string]any); ok {
 		if cfg, ok := testRaw["configuration"].(string); ok {
@@ -357,38 +1057,355 @@ func (p *SwiftPMPlugin) parseConfig(raw map[string]any) *Config {
 		if par, ok := testRaw["parallel"].(bool); ok {
 			testConfig.Parallel = par
 		}
+		if report, ok := testRaw["report_path"].(string); ok {
+			testConfig.ReportPath = report
+		}
+		switch maxFailures := testRaw["max_failures"].(type) {
+		case int:
+			testConfig.MaxFailures = maxFailures
+		case float64:
+			testConfig.MaxFailures = int(maxFailures)
+		}
 	}
 
 	// Parse archive config
 	archiveConfig := ArchiveConfig{
-		IncludeDocs: true,
-		Exclude:     exclude,
+		IncludeDocs:    true,
+		Exclude:        exclude,
+		MaxFileSize:    defaultMaxFileSize,
+		MaxArchiveSize: defaultMaxArchiveSize,
+		Formats:        []string{FormatZip},
 	}
 	if archiveRaw, ok := raw["archive"].(map[string]any); ok {
 		if inc, ok := archiveRaw["include_docs"].(bool); ok {
 			archiveConfig.IncludeDocs = inc
 		}
+		if val, ok := archiveRaw["validate"].(bool); ok {
+			archiveConfig.Validate = val
+		}
+		if sym, ok := archiveRaw["preserve_symlinks"].(bool); ok {
+			archiveConfig.PreserveSymlinks = sym
+		}
+		if size, ok := parseByteSize(archiveRaw["max_file_size"]); ok {
+			archiveConfig.MaxFileSize = size
+		}
+		if size, ok := parseByteSize(archiveRaw["max_archive_size"]); ok {
+			archiveConfig.MaxArchiveSize = size
+		}
+		if dir, ok := archiveRaw["output_dir"].(string); ok {
+			archiveConfig.OutputDir = dir
+		}
+		switch workers := archiveRaw["workers"].(type) {
+		case int:
+			archiveConfig.Workers = workers
+		case float64:
+			archiveConfig.Workers = int(workers)
+		}
+		if formatList, ok := archiveRaw["formats"].([]any); ok && len(formatList) > 0 {
+			archiveConfig.Formats = nil
+			for _, f := range formatList {
+				if s, ok := f.(string); ok {
+					archiveConfig.Formats = append(archiveConfig.Formats, s)
+				}
+			}
+		}
+		if digestList, ok := archiveRaw["digests"].([]any); ok {
+			for _, d := range digestList {
+				if s, ok := d.(string); ok {
+					archiveConfig.Digests = append(archiveConfig.Digests, s)
+				}
+			}
+		}
+	}
+
+	// Parse binary targets
+	var binaryTargets []BinaryTargetConfig
+	if targetList, ok := raw["binary_targets"].([]any); ok {
+		for _, t := range targetList {
+			targetRaw, ok := t.(map[string]any)
+			if !ok {
+				continue
+			}
+			var target BinaryTargetConfig
+			target.Name, _ = targetRaw["name"].(string)
+			target.Path, _ = targetRaw["path"].(string)
+			target.URL, _ = targetRaw["url"].(string)
+			target.Publish, _ = targetRaw["publish"].(bool)
+			binaryTargets = append(binaryTargets, target)
+		}
+	}
+
+	// Parse version files
+	var versionFiles []VersionFileConfig
+	if fileList, ok := raw["version_files"].([]any); ok {
+		for _, f := range fileList {
+			fileRaw, ok := f.(map[string]any)
+			if !ok {
+				continue
+			}
+			var file VersionFileConfig
+			file.Path, _ = fileRaw["path"].(string)
+			file.Constant, _ = fileRaw["constant"].(string)
+			file.Major, _ = fileRaw["major"].(string)
+			file.Minor, _ = fileRaw["minor"].(string)
+			file.Patch, _ = fileRaw["patch"].(string)
+			file.Prerelease, _ = fileRaw["prerelease"].(string)
+			file.Pattern, _ = fileRaw["pattern"].(string)
+			file.Template, _ = fileRaw["template"].(string)
+			versionFiles = append(versionFiles, file)
+		}
+	}
+
+	// Parse version source config
+	var versionSource VersionSourceConfig
+	if sourceRaw, ok := raw["version_source"].(map[string]any); ok {
+		versionSource.Directory, _ = sourceRaw["directory"].(string)
+		versionSource.FileName, _ = sourceRaw["file_name"].(string)
+		versionSource.TypeName, _ = sourceRaw["type_name"].(string)
+		versionSource.Access, _ = sourceRaw["access"].(string)
+		versionSource.Template, _ = sourceRaw["template"].(string)
+		versionSource.TemplateFile, _ = sourceRaw["template_file"].(string)
+	}
+
+	// Parse marketing version config
+	var marketing MarketingVersionConfig
+	if marketingRaw, ok := raw["marketing_version"].(map[string]any); ok {
+		if pathList, ok := marketingRaw["paths"].([]any); ok {
+			for _, p := range pathList {
+				if s, ok := p.(string); ok {
+					marketing.Paths = append(marketing.Paths, s)
+				}
+			}
+		}
+		marketing.Template, _ = marketingRaw["template"].(string)
+	}
+
+	// Parse podspec config
+	podspec := PodspecConfig{Check: true}
+	if podspecRaw, ok := raw["podspec"].(map[string]any); ok {
+		podspec.Enabled, _ = podspecRaw["enabled"].(bool)
+		if pathList, ok := podspecRaw["paths"].([]any); ok {
+			for _, p := range pathList {
+				if s, ok := p.(string); ok {
+					podspec.Paths = append(podspec.Paths, s)
+				}
+			}
+		}
+		if check, ok := podspecRaw["check"].(bool); ok {
+			podspec.Check = check
+		}
+	}
+
+	// Parse dependency policy config
+	var dependencyPolicy DependencyPolicyConfig
+	if policyRaw, ok := raw["dependency_policy"].(map[string]any); ok {
+		if enabled, ok := policyRaw["enabled"].(bool); ok {
+			dependencyPolicy.Enabled = enabled
+		}
+		if allowList, ok := policyRaw["allow"].([]any); ok {
+			for _, a := range allowList {
+				if s, ok := a.(string); ok {
+					dependencyPolicy.Allow = append(dependencyPolicy.Allow, s)
+				}
+			}
+		}
+	}
+
+	// Parse lint config
+	var lint LintConfig
+	if lintRaw, ok := raw["lint"].(map[string]any); ok {
+		if enabled, ok := lintRaw["enabled"].(bool); ok {
+			lint.Enabled = enabled
+		}
+		lint.MinToolsVersion, _ = lintRaw["min_tools_version"].(string)
+		if rules, ok := lintRaw["rules"].(map[string]any); ok {
+			lint.Rules = make(map[string]string, len(rules))
+			for name, severity := range rules {
+				if s, ok := severity.(string); ok {
+					lint.Rules[name] = s
+				}
+			}
+		}
+	}
+
+	// Parse registry dependencies config
+	var registryDependencies RegistryDependenciesConfig
+	if registryRaw, ok := raw["registry_dependencies"].(map[string]any); ok {
+		registryDependencies.Enabled, _ = registryRaw["enabled"].(bool)
+		registryDependencies.Lookup, _ = registryRaw["lookup"].(bool)
+		if idMap, ok := registryRaw["identifiers"].(map[string]any); ok {
+			registryDependencies.Identifiers = make(map[string]string, len(idMap))
+			for url, id := range idMap {
+				if s, ok := id.(string); ok {
+					registryDependencies.Identifiers[url] = s
+				}
+			}
+		}
+	}
+
+	// Parse API breakage config
+	var apiBreakage APIBreakageConfig
+	if breakageRaw, ok := raw["api_breakage"].(map[string]any); ok {
+		apiBreakage.Enabled, _ = breakageRaw["enabled"].(bool)
+		if allowMap, ok := breakageRaw["allow"].(map[string]any); ok {
+			apiBreakage.Allow = make(map[string][]string, len(allowMap))
+			for name, list := range allowMap {
+				entries, _ := list.([]any)
+				for _, e := range entries {
+					if s, ok := e.(string); ok {
+						apiBreakage.Allow[name] = append(apiBreakage.Allow[name], s)
+					}
+				}
+			}
+		}
+	}
+
+	// Parse manifest compatibility config
+	manifestCompat := ManifestCompatibilityConfig{Source: ManifestSourceGit}
+	if compatRaw, ok := raw["manifest_compatibility"].(map[string]any); ok {
+		manifestCompat.Enabled, _ = compatRaw["enabled"].(bool)
+		manifestCompat.AllowPartial, _ = compatRaw["allow_partial"].(bool)
+		if source, ok := compatRaw["source"].(string); ok {
+			manifestCompat.Source = source
+		}
+		if allowList, ok := compatRaw["allow"].([]any); ok {
+			for _, a := range allowList {
+				if s, ok := a.(string); ok {
+					manifestCompat.Allow = append(manifestCompat.Allow, s)
+				}
+			}
+		}
+	}
+
+	// Parse secret scan config
+	secretScan := SecretScanConfig{AllowlistFile: ".secrets-allowlist"}
+	if scanRaw, ok := raw["secret_scan"].(map[string]any); ok {
+		if enabled, ok := scanRaw["enabled"].(bool); ok {
+			secretScan.Enabled = enabled
+		}
+		if allowlist, ok := scanRaw["allowlist_file"].(string); ok {
+			secretScan.AllowlistFile = allowlist
+		}
 	}
 
 	return &Config{
-		Registry:        parser.GetString("registry", "SWIFT_REGISTRY_URL", "https://swift.pkg.github.com"),
-		Scope:           parser.GetString("scope", "SWIFT_PACKAGE_SCOPE", ""),
-		Token:           parser.GetString("token", "SWIFT_REGISTRY_TOKEN", ""),
-		PackageName:     parser.GetString("package_name", "", ""),
-		ManifestPath:    parser.GetString("manifest_path", "", "Package.swift"),
-		UpdateManifest:  parser.GetBool("update_manifest", false),
-		VersionConstant: parser.GetString("version_constant", "", "packageVersion"),
-		CreateTag:       parser.GetBool("create_tag", true),
-		TagPrefix:       parser.GetString("tag_prefix", "", ""),
-		Validate:        parser.GetBool("validate", true),
-		Build:           parser.GetBool("build", true),
-		Test:            parser.GetBool("test", true),
-		TestConfig:      testConfig,
-		Archive:         archiveConfig,
-		DryRun:          parser.GetBool("dry_run", false),
+		Registry:              parser.GetString("registry", "SWIFT_REGISTRY_URL", "https://swift.pkg.github.com"),
+		Scope:                 parser.GetString("scope", "SWIFT_PACKAGE_SCOPE", ""),
+		Token:                 parser.GetString("token", "SWIFT_REGISTRY_TOKEN", ""),
+		PackageName:           parser.GetString("package_name", "", ""),
+		ManifestPath:          parser.GetString("manifest_path", "", "Package.swift"),
+		UpdateManifest:        parser.GetBool("update_manifest", false),
+		VersionConstant:       parser.GetString("version_constant", "", "packageVersion"),
+		CreateTag:             parser.GetBool("create_tag", true),
+		TagPrefix:             parser.GetString("tag_prefix", "", ""),
+		Validate:              parser.GetBool("validate", true),
+		Build:                 parser.GetBool("build", true),
+		Test:                  parser.GetBool("test", true),
+		TestConfig:            testConfig,
+		Archive:               archiveConfig,
+		BinaryTargets:         binaryTargets,
+		VersionFiles:          versionFiles,
+		VersionSource:         versionSource,
+		MarketingVersion:      marketing,
+		Podspec:               podspec,
+		DependencyPolicy:      dependencyPolicy,
+		Lint:                  lint,
+		RegistryDependencies:  registryDependencies,
+		APIBreakage:           apiBreakage,
+		ManifestCompatibility: manifestCompat,
+		LogDir:                parser.GetString("log_dir", "", ""),
+		SecretScan:            secretScan,
+		DryRun:                parser.GetBool("dry_run", false),
+	}
+}
+
+// parseByteSize converts a size given as a number of bytes or as a string
+// with a unit suffix (e.g. "100MB", "1.5GiB") to bytes.
+func parseByteSize(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case string:
+		s := strings.TrimSpace(strings.ToUpper(n))
+		units := []struct {
+			suffix string
+			factor float64
+		}{
+			{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+			{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+			{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+			{"B", 1},
+		}
+		factor := 1.0
+		for _, u := range units {
+			if strings.HasSuffix(s, u.suffix) {
+				s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+				factor = u.factor
+				break
+			}
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			return 0, false
+		}
+		return int64(f * factor), true
+	default:
+		return 0, false
 	}
 }
 
+// previousReleaseManifest returns the Package.swift source of the release
+// before the current one and the tag or version it was read from, which is
+// "" when there is no previous release.
+func previousReleaseManifest(ctx context.Context, workDir string, cfg *Config, releaseCtx *plugin.ReleaseContext, loadManifest func() (*PackageManifest, error)) (string, string, error) {
+	if cfg.ManifestCompatibility.Source == ManifestSourceRegistry && releaseCtx.PreviousVersion != "" {
+		return publishedManifest(ctx, cfg, releaseCtx.PreviousVersion, loadManifest)
+	}
+
+	tag, err := previousReleaseTag(ctx, workDir, cfg.TagPrefix, releaseCtx.Version)
+	if err != nil || tag == "" {
+		return "", "", err
+	}
+	if cfg.ManifestCompatibility.Source == ManifestSourceRegistry {
+		return publishedManifest(ctx, cfg, strings.TrimPrefix(tag, cfg.TagPrefix), loadManifest)
+	}
+	source, err := manifestAtTag(ctx, workDir, tag)
+	if err != nil {
+		return "", "", err
+	}
+	return source, tag, nil
+}
+
+// publishedManifest fetches the Package.swift of a published version.
+func publishedManifest(ctx context.Context, cfg *Config, version string, loadManifest func() (*PackageManifest, error)) (string, string, error) {
+	packageName := cfg.PackageName
+	if packageName == "" {
+		manifest, err := loadManifest()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse Package.swift: %w", err)
+		}
+		packageName = manifest.Name
+	}
+	source, err := NewRegistryClient(cfg.Registry, cfg.Token).GetManifest(ctx, cfg.Scope, packageName, version)
+	if err != nil {
+		return "", "", err
+	}
+	return source, version, nil
+}
+
+// resolvePath resolves a configured path against workDir. An empty path
+// stays empty.
+func resolvePath(workDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
+}
+
 func createGitTag(ctx context.Context, tag string) error {
 	cmd := exec.CommandContext(ctx, "git", "tag", tag)
 	if output, err := cmd.CombinedOutput(); err != nil {
@@ -396,3 +1413,18 @@ func createGitTag(ctx context.Context, tag string) error {
 	}
 	return nil
 }
+
+// generateVersionSource resolves the release commit and date and generates
+// the configured version source file, writing it unless in dry-run mode.
+func generateVersionSource(ctx context.Context, workDir string, cfg *Config, releaseCtx *plugin.ReleaseContext) (*GeneratedFile, error) {
+	commit, err := resolveCommit(ctx, workDir, releaseCtx.CommitSHA)
+	if err != nil {
+		return nil, err
+	}
+	when, err := releaseTime()
+	if err != nil {
+		return nil, err
+	}
+	data := newVersionSourceData(cfg.VersionSource, newTemplateData(releaseCtx.Version, cfg.TagPrefix), commit, when)
+	return GenerateVersionSource(workDir, cfg.VersionSource, data, !cfg.DryRun)
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"archive/zip"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
@@ -68,8 +77,12 @@ func TestSwiftPMPlugin_ParseConfig(t *testing.T) {
 					Parallel:      true,
 				},
 				Archive: ArchiveConfig{
-					IncludeDocs: true,
-					Exclude:     []string{".git", ".build", "Tests", "*.xcodeproj"},
+					IncludeDocs:    true,
+					Exclude:        []string{".git", ".build", "Tests", "*.xcodeproj"},
+					Validate:       false,
+					MaxFileSize:    defaultMaxFileSize,
+					MaxArchiveSize: defaultMaxArchiveSize,
+					Formats:        []string{FormatZip},
 				},
 			},
 		},
@@ -105,8 +118,12 @@ func TestSwiftPMPlugin_ParseConfig(t *testing.T) {
 					Parallel:      true,
 				},
 				Archive: ArchiveConfig{
-					IncludeDocs: true,
-					Exclude:     []string{".git", ".build", "Tests", "*.xcodeproj"},
+					IncludeDocs:    true,
+					Exclude:        []string{".git", ".build", "Tests", "*.xcodeproj"},
+					Validate:       false,
+					MaxFileSize:    defaultMaxFileSize,
+					MaxArchiveSize: defaultMaxArchiveSize,
+					Formats:        []string{FormatZip},
 				},
 			},
 		},
@@ -133,8 +150,12 @@ func TestSwiftPMPlugin_ParseConfig(t *testing.T) {
 					Parallel:      false,
 				},
 				Archive: ArchiveConfig{
-					IncludeDocs: true,
-					Exclude:     []string{".git", ".build", "Tests", "*.xcodeproj"},
+					IncludeDocs:    true,
+					Exclude:        []string{".git", ".build", "Tests", "*.xcodeproj"},
+					Validate:       false,
+					MaxFileSize:    defaultMaxFileSize,
+					MaxArchiveSize: defaultMaxArchiveSize,
+					Formats:        []string{FormatZip},
 				},
 			},
 		},
@@ -144,6 +165,8 @@ func TestSwiftPMPlugin_ParseConfig(t *testing.T) {
 				"archive": map[string]any{
 					"include_docs": false,
 					"exclude":      []any{"custom-dir", "*.log"},
+					"formats":      []any{"zip", "tar.gz"},
+					"digests":      []any{"sha-512"},
 				},
 			},
 			expected: &Config{
@@ -160,8 +183,13 @@ func TestSwiftPMPlugin_ParseConfig(t *testing.T) {
 					Parallel:      true,
 				},
 				Archive: ArchiveConfig{
-					IncludeDocs: false,
-					Exclude:     []string{"custom-dir", "*.log"},
+					IncludeDocs:    false,
+					Exclude:        []string{"custom-dir", "*.log"},
+					Validate:       false,
+					MaxFileSize:    defaultMaxFileSize,
+					MaxArchiveSize: defaultMaxArchiveSize,
+					Formats:        []string{FormatZip, FormatTarGz},
+					Digests:        []string{DigestSHA512},
 				},
 			},
 		},
@@ -213,6 +241,21 @@ func TestSwiftPMPlugin_ParseConfig(t *testing.T) {
 			if cfg.Archive.IncludeDocs != tt.expected.Archive.IncludeDocs {
 				t.Errorf("expected archive include_docs %v, got %v", tt.expected.Archive.IncludeDocs, cfg.Archive.IncludeDocs)
 			}
+			if cfg.Archive.MaxFileSize != tt.expected.Archive.MaxFileSize {
+				t.Errorf("expected archive max_file_size %d, got %d", tt.expected.Archive.MaxFileSize, cfg.Archive.MaxFileSize)
+			}
+			if cfg.Archive.MaxArchiveSize != tt.expected.Archive.MaxArchiveSize {
+				t.Errorf("expected archive max_archive_size %d, got %d", tt.expected.Archive.MaxArchiveSize, cfg.Archive.MaxArchiveSize)
+			}
+			if cfg.Archive.Validate != tt.expected.Archive.Validate {
+				t.Errorf("expected archive validate %v, got %v", tt.expected.Archive.Validate, cfg.Archive.Validate)
+			}
+			if !slices.Equal(cfg.Archive.Formats, tt.expected.Archive.Formats) {
+				t.Errorf("expected archive formats %v, got %v", tt.expected.Archive.Formats, cfg.Archive.Formats)
+			}
+			if !slices.Equal(cfg.Archive.Digests, tt.expected.Archive.Digests) {
+				t.Errorf("expected archive digests %v, got %v", tt.expected.Archive.Digests, cfg.Archive.Digests)
+			}
 			if len(cfg.Archive.Exclude) != len(tt.expected.Archive.Exclude) {
 				t.Errorf("expected %d exclude patterns, got %d", len(tt.expected.Archive.Exclude), len(cfg.Archive.Exclude))
 			}
@@ -287,6 +330,56 @@ let package = Package(name: "TestPackage")
 			wantErrors: true,
 			errorField: "registry",
 		},
+		{
+			name: "unsupported archive format",
+			config: map[string]any{
+				"scope":         "myorg",
+				"token":         "secret-token",
+				"manifest_path": manifestPath,
+				"archive": map[string]any{
+					"formats": []any{"zip", "tar.bz2"},
+				},
+			},
+			wantErrors: true,
+			errorField: "archive.formats",
+		},
+		{
+			name: "unsupported digest algorithm",
+			config: map[string]any{
+				"scope":         "myorg",
+				"token":         "secret-token",
+				"manifest_path": manifestPath,
+				"archive": map[string]any{
+					"digests": []any{"md5"},
+				},
+			},
+			wantErrors: true,
+			errorField: "archive.digests",
+		},
+		{
+			name: "version file without constant or pattern",
+			config: map[string]any{
+				"scope":         "myorg",
+				"token":         "secret-token",
+				"manifest_path": manifestPath,
+				"version_files": []any{
+					map[string]any{"path": "Sources/**/Version.swift"},
+				},
+			},
+			wantErrors: true,
+			errorField: "version_files",
+		},
+		{
+			name: "marketing version for unsupported file",
+			config: map[string]any{
+				"scope":             "myorg",
+				"token":             "secret-token",
+				"manifest_path":     manifestPath,
+				"marketing_version": map[string]any{"paths": []any{"Sources/**/Version.swift"}},
+			},
+			wantErrors: true,
+			errorField: "marketing_version",
+		},
 	}
 
 	for _, tt := range tests {
@@ -321,6 +414,42 @@ let package = Package(name: "TestPackage")
 	}
 }
 
+func TestSwiftPMPlugin_Validate_SwiftRequirement(t *testing.T) {
+	p := &SwiftPMPlugin{}
+	t.Setenv("PATH", t.TempDir())
+
+	manifestPath := filepath.Join(t.TempDir(), "Package.swift")
+	if err := os.WriteFile(manifestPath, []byte(`let package = Package(name: "TestPackage")`), 0644); err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		steps     map[string]any
+		wantSwift bool
+	}{
+		{"default steps", map[string]any{}, true},
+		{"build only", map[string]any{"validate": false, "build": true, "test": false}, true},
+		{"no swift steps", map[string]any{"validate": false, "build": false, "test": false}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"scope": "myorg", "token": "secret-token", "manifest_path": manifestPath}
+			maps.Copy(config, tt.steps)
+
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			gotSwift := slices.ContainsFunc(resp.Errors, func(e plugin.ValidationError) bool { return e.Field == "swift" })
+			if gotSwift != tt.wantSwift {
+				t.Errorf("swift error = %v, want %v (errors: %v)", gotSwift, tt.wantSwift, resp.Errors)
+			}
+		})
+	}
+}
+
 func TestSwiftPMPlugin_Execute_DryRun(t *testing.T) {
 	p := &SwiftPMPlugin{}
 
@@ -376,7 +505,585 @@ let package = Package(name: "TestPackage")
 	}
 }
 
+func TestSwiftPMPlugin_Execute_RegistryDependencies(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identifiers" {
+			t.Errorf("dry run must only look up identifiers: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("url") == "https://github.com/mona/LinkedList" {
+			_, _ = w.Write([]byte(`{"identifiers": ["mona.LinkedList"]}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	variant := strings.Replace(registryManifest, "swift-tools-version:5.9", "swift-tools-version:5.8", 1)
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift":           registryManifest,
+		"Package@swift-5.8.swift": variant,
+		"Sources/Kit/kit.swift":   "public func kit() {}",
+	})
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+		Config: map[string]any{
+			"scope":         "testorg",
+			"token":         "test-token",
+			"package_name":  "Kit",
+			"manifest_path": filepath.Join(tempDir, "Package.swift"),
+			"registry":      server.URL,
+			"create_tag":    false,
+			"archive":       map[string]any{"validate": false, "output_dir": "release"},
+			"registry_dependencies": map[string]any{
+				"enabled":     true,
+				"lookup":      true,
+				"identifiers": map[string]any{"https://github.com/apple/swift-argument-parser": "apple.swift-argument-parser"},
+			},
+		},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatalf("PostPublish failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("PostPublish should succeed in dry-run mode: %s", resp.Message)
+	}
+
+	rewrites, ok := resp.Outputs["registry_dependencies"].([]RegistryDependencyRewrite)
+	if !ok || len(rewrites) != 4 {
+		t.Fatalf("unexpected registry_dependencies output %+v", resp.Outputs["registry_dependencies"])
+	}
+	if rewrites[0].Manifest != "Package.swift" || rewrites[2].Manifest != "Package@swift-5.8.swift" {
+		t.Errorf("rewrites should name their manifest: %+v", rewrites)
+	}
+	diff, _ := resp.Outputs["manifest_diff"].(string)
+	for _, expected := range []string{
+		"+++ b/Package.swift",
+		"+++ b/Package@swift-5.8.swift",
+		`-        .package(url: "https://github.com/apple/swift-argument-parser.git", from: "1.2.0"),`,
+		`+        .package(id: "apple.swift-argument-parser", from: "1.2.0"),`,
+		`+            .product(name: "LinkedList", package: "mona.LinkedList"),`,
+	} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("manifest diff missing %q:\n%s", expected, diff)
+		}
+	}
+
+	// Both manifests are archived rewritten
+	reader, err := zip.OpenReader(filepath.Join(tempDir, "release", "testorg.Kit-1.0.0.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reader.Close() }()
+	archived := make(map[string]bool)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		archived[f.Name] = strings.Contains(string(content), `.package(id: "mona.LinkedList"`)
+	}
+	for _, name := range []string{"Package.swift", "Package@swift-5.8.swift"} {
+		if !archived[name] {
+			t.Errorf("archived %s should use registry dependencies", name)
+		}
+	}
+
+	for name, original := range map[string]string{"Package.swift": registryManifest, "Package@swift-5.8.swift": variant} {
+		if content, _ := os.ReadFile(filepath.Join(tempDir, name)); string(content) != original {
+			t.Errorf("working tree %s was modified", name)
+		}
+	}
+}
+
+func TestSwiftPMPlugin_Execute_DryRunArchivePreview(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry run must not contact the registry: %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift":          "// swift-tools-version:5.7",
+		"Sources/Lib/lib.swift":  "public func greet() {}",
+		"Tests/LibTests/t.swift": "import XCTest",
+		".build/debug/Lib.o":     "binary",
+	})
+
+	req := plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+		Config: map[string]any{
+			"scope":         "testorg",
+			"token":         "test-token",
+			"package_name":  "TestPackage",
+			"manifest_path": filepath.Join(tempDir, "Package.swift"),
+			"registry":      server.URL,
+			"create_tag":    false,
+			"archive": map[string]any{
+				"validate": false, // Requires Swift CLI
+			},
+		},
+		DryRun: true,
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("PostPublish failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("PostPublish should succeed in dry-run mode: %s", resp.Message)
+	}
+
+	archive, ok := resp.Outputs["archive"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected archive outputs, got %v", resp.Outputs)
+	}
+	if checksum, _ := archive["checksum"].(string); len(checksum) != 64 {
+		t.Errorf("expected real sha256 checksum, got %q", checksum)
+	}
+	if count, _ := archive["file_count"].(int); count != 2 {
+		t.Errorf("expected 2 files, got %v", archive["file_count"])
+	}
+
+	files, _ := resp.Outputs["archive_files"].([]string)
+	expected := []string{"Package.swift", "Sources/Lib/lib.swift"}
+	if len(files) != len(expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("expected file %s, got %s", expected[i], files[i])
+		}
+	}
+}
+
+func TestSwiftPMPlugin_Execute_OutputDir(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift":           "// swift-tools-version:5.7",
+		"Sources/Lib/lib.swift":   "public func greet() {}",
+		"release/old-release.zip": "previous release",
+	})
+
+	req := plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Context: plugin.ReleaseContext{Version: "2.0.0"},
+		Config: map[string]any{
+			"scope":         "testorg",
+			"package_name":  "Lib",
+			"manifest_path": filepath.Join(tempDir, "Package.swift"),
+			"registry":      "",
+			"create_tag":    false,
+			"archive": map[string]any{
+				"validate":   false, // Requires Swift CLI
+				"output_dir": "release",
+			},
+		},
+		DryRun: true,
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("PostPublish failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("PostPublish failed: %s", resp.Message)
+	}
+
+	archivePath := filepath.Join(tempDir, "release", "testorg.Lib-2.0.0.zip")
+	for _, path := range []string{archivePath, archivePath + ".sha256", filepath.Join(tempDir, "release", "testorg.Lib-2.0.0.contents.json")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+		}
+	}
+
+	if len(resp.Artifacts) != 3 || resp.Artifacts[0].Path != archivePath {
+		t.Errorf("unexpected artifacts %+v", resp.Artifacts)
+	}
+
+	files, _ := resp.Outputs["archive_files"].([]string)
+	for _, f := range files {
+		if strings.HasPrefix(f, "release/") {
+			t.Errorf("output directory must not be archived, found %s", f)
+		}
+	}
+}
+
+func TestSwiftPMPlugin_Execute_VersionFiles(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift":              "// swift-tools-version:5.9\nimport PackageDescription\nlet package = Package(name: \"Kit\")\n",
+		"Sources/Core/Version.swift": "public enum Kit {\n    public static let version = \"1.0.0\"\n    public static let major = 1\n}\n",
+	})
+	versionPath := filepath.Join(tempDir, "Sources", "Core", "Version.swift")
+
+	config := map[string]any{
+		"manifest_path": filepath.Join(tempDir, "Package.swift"),
+		"validate":      false,
+		"build":         false,
+		"test":          false,
+		"version_files": []any{
+			map[string]any{"path": "Sources/**/Version.swift", "constant": "version", "major": "major"},
+		},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "2.0.0"},
+			Config:  config,
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("PrePublish should succeed: %s", resp.Message)
+		}
+
+		replacements, ok := resp.Outputs["version_files"].([]VersionReplacement)
+		if !ok || len(replacements) != 2 {
+			t.Fatalf("expected 2 version file replacements, got %+v", resp.Outputs["version_files"])
+		}
+
+		content, err := os.ReadFile(versionPath)
+		if err != nil {
+			t.Fatalf("failed to read version file: %v", err)
+		}
+		if updated := strings.Contains(string(content), `version = "2.0.0"`); updated == dryRun {
+			t.Errorf("dry_run=%v: version file updated = %v:\n%s", dryRun, updated, content)
+		}
+	}
+}
+
+func TestSwiftPMPlugin_Execute_MarketingVersion(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": "// swift-tools-version:5.9\nimport PackageDescription\nlet package = Package(name: \"Kit\")\n",
+		"Example/Example.xcodeproj/project.pbxproj": testPbxproj,
+		"Example/Example/Info.plist":                testInfoPlist,
+	})
+	plistPath := filepath.Join(tempDir, "Example", "Example", "Info.plist")
+
+	config := map[string]any{
+		"manifest_path": filepath.Join(tempDir, "Package.swift"),
+		"validate":      false,
+		"build":         false,
+		"test":          false,
+		"marketing_version": map[string]any{
+			"paths": []any{"Example/**/project.pbxproj", "Example/**/Info.plist"},
+		},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.5.0-beta.2"},
+			Config:  config,
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("PrePublish should succeed: %s", resp.Message)
+		}
+
+		replacements, ok := resp.Outputs["marketing_versions"].([]VersionReplacement)
+		if !ok || len(replacements) != 3 {
+			t.Fatalf("expected 3 marketing version replacements, got %+v", resp.Outputs["marketing_versions"])
+		}
+
+		content, err := os.ReadFile(plistPath)
+		if err != nil {
+			t.Fatalf("failed to read Info.plist: %v", err)
+		}
+		if updated := strings.Contains(string(content), "<string>1.5.0</string>"); updated == dryRun {
+			t.Errorf("dry_run=%v: Info.plist updated = %v:\n%s", dryRun, updated, content)
+		}
+	}
+}
+
+func TestSwiftPMPlugin_Execute_Podspec(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": "// swift-tools-version:5.9\nimport PackageDescription\nlet package = Package(\n    name: \"Kit\",\n    platforms: [.iOS(.v13), .macOS(.v10_15)]\n)\n",
+		"Kit.podspec":   testPodspec,
+	})
+	podspecPath := filepath.Join(tempDir, "Kit.podspec")
+
+	config := map[string]any{
+		"manifest_path": filepath.Join(tempDir, "Package.swift"),
+		"tag_prefix":    "v",
+		"validate":      false,
+		"build":         false,
+		"test":          false,
+		"podspec":       map[string]any{"enabled": true},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.1.0"},
+			Config:  config,
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("PrePublish should succeed: %s", resp.Message)
+		}
+
+		specs, ok := resp.Outputs["podspecs"].([]Podspec)
+		if !ok || len(specs) != 1 || len(specs[0].Replacements) != 1 {
+			t.Fatalf("unexpected podspecs output %+v", resp.Outputs["podspecs"])
+		}
+
+		content, err := os.ReadFile(podspecPath)
+		if err != nil {
+			t.Fatalf("failed to read podspec: %v", err)
+		}
+		if updated := strings.Contains(string(content), "s.version      = '1.1.0'"); updated == dryRun {
+			t.Errorf("dry_run=%v: podspec updated = %v:\n%s", dryRun, updated, content)
+		}
+	}
+
+	// A podspec disagreeing with Package.swift fails the release
+	writeTestFiles(t, tempDir, map[string]string{"Kit.podspec": strings.Replace(testPodspec, "'10.15'", "'11.0'", 1)})
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPrePublish,
+		Context: plugin.ReleaseContext{Version: "1.1.0"},
+		Config:  config,
+	})
+	if err != nil {
+		t.Fatalf("PrePublish failed: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "macos deployment target 11.0 does not match Package.swift minimum 10.15") {
+		t.Errorf("expected podspec check failure, got %+v", resp)
+	}
+}
+
+func TestSwiftPMPlugin_Execute_DependencyPolicy(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-argument-parser.git", from: "1.2.0"),
+        .package(url: "https://github.com/jpsim/Yams", branch: "main"),
+    ]
+)
+`,
+		"Package.resolved": resolvedV2,
+	})
+
+	config := map[string]any{
+		"manifest_path":     filepath.Join(tempDir, "Package.swift"),
+		"validate":          false,
+		"build":             false,
+		"test":              false,
+		"dependency_policy": map[string]any{"enabled": true},
+	}
+	execute := func() *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.0.0"},
+			Config:  config,
+			DryRun:  true,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		return resp
+	}
+
+	if resp := execute(); resp.Success || !strings.Contains(resp.Message, "yams uses a branch requirement (main)") {
+		t.Errorf("expected branch dependency to fail PrePublish, got %+v", resp)
+	}
+
+	config["dependency_policy"] = map[string]any{"enabled": true, "allow": []any{"yams"}}
+	if resp := execute(); !resp.Success {
+		t.Errorf("allowed branch dependency should pass: %s", resp.Message)
+	}
+
+	writeTestFiles(t, tempDir, map[string]string{"Package.resolved": strings.Replace(resolvedV2, `"1.2.0"`, `"1.1.0"`, 1)})
+	if resp := execute(); resp.Success || !strings.Contains(resp.Message, "Package.resolved is stale") {
+		t.Errorf("expected stale Package.resolved to fail PrePublish, got %+v", resp)
+	}
+
+	delete(config, "dependency_policy")
+	if resp := execute(); !resp.Success {
+		t.Errorf("dependency policy should be disabled by default: %s", resp.Message)
+	}
+}
+
+func TestSwiftPMPlugin_Execute_VersionSource(t *testing.T) {
+	p := &SwiftPMPlugin{}
+	t.Setenv("SOURCE_DATE_EPOCH", "1734620645")
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift":         "// swift-tools-version:5.9\nimport PackageDescription\nlet package = Package(name: \"Kit\")\n",
+		"Sources/Kit/Kit.swift": "public struct Kit {}",
+	})
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPrePublish,
+		Context: plugin.ReleaseContext{Version: "1.2.0", CommitSHA: "0123456789abcdef0123456789abcdef01234567"},
+		Config: map[string]any{
+			"manifest_path":  filepath.Join(tempDir, "Package.swift"),
+			"tag_prefix":     "v",
+			"validate":       false,
+			"build":          false,
+			"test":           false,
+			"version_source": map[string]any{"directory": "Sources/Kit", "type_name": "KitVersion"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrePublish failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("PrePublish should succeed: %s", resp.Message)
+	}
+
+	file, ok := resp.Outputs["version_source"].(*GeneratedFile)
+	if !ok || file.Path != "Sources/Kit/Version.swift" || file.Date != "2024-12-19" {
+		t.Fatalf("unexpected version_source output %+v", resp.Outputs["version_source"])
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "Sources", "Kit", "Version.swift"))
+	if err != nil {
+		t.Fatalf("version source not written: %v", err)
+	}
+	for _, expected := range []string{
+		"public enum KitVersion {",
+		`public static let tag = "v1.2.0"`,
+		`public static let commit = "0123456789abcdef0123456789abcdef01234567"`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected generated source to contain %s", expected)
+		}
+	}
+}
+
+func TestSwiftPMPlugin_Execute_BinaryTargets(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift":                    binaryTargetManifest,
+		"Sources/Kit/kit.swift":            "public func kit() {}",
+		"build/KitCore.xcframework.zip":    "hello",
+		"build/KitTool.artifactbundle.zip": "tool",
+	})
+	manifestPath := filepath.Join(tempDir, "Package.swift")
+
+	config := map[string]any{
+		"scope":         "testorg",
+		"package_name":  "Kit",
+		"manifest_path": manifestPath,
+		"tag_prefix":    "v",
+		"validate":      false,
+		"build":         false,
+		"test":          false,
+		"create_tag":    false,
+		"archive":       map[string]any{"validate": false},
+		"binary_targets": []any{
+			map[string]any{
+				"name":    "KitCore",
+				"path":    "build/KitCore.xcframework.zip",
+				"url":     "https://example.com/{{.Tag}}/KitCore.xcframework.zip",
+				"publish": true,
+			},
+			map[string]any{
+				"name": "KitTool",
+				"path": "build/KitTool.artifactbundle.zip",
+				"url":  "https://example.com/{{.Version}}/KitTool.artifactbundle.zip",
+			},
+		},
+	}
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPrePublish,
+		Context: plugin.ReleaseContext{Version: "2.0.0"},
+		Config:  config,
+	})
+	if err != nil {
+		t.Fatalf("PrePublish failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("PrePublish should succeed: %s", resp.Message)
+	}
+
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	for _, expected := range []string{
+		`url: "https://example.com/v2.0.0/KitCore.xcframework.zip"`,
+		`checksum: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`,
+		`url: "https://example.com/2.0.0/KitTool.artifactbundle.zip"`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected manifest to contain %s", expected)
+		}
+	}
+	if len(resp.Artifacts) != 1 || resp.Artifacts[0].Name != "KitCore.xcframework.zip" {
+		t.Errorf("expected only the published artifact, got %+v", resp.Artifacts)
+	}
+
+	// Artifacts are not shipped in the source archive
+	resp, err = p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Context: plugin.ReleaseContext{Version: "2.0.0"},
+		Config:  config,
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("PostPublish failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("PostPublish should succeed in dry-run mode: %s", resp.Message)
+	}
+	files, _ := resp.Outputs["archive_files"].([]string)
+	if !slices.Equal(files, []string{"Package.swift", "Sources/Kit/kit.swift"}) {
+		t.Errorf("unexpected archive files %v", files)
+	}
+}
+
 func TestRegistryClient_Publish(t *testing.T) {
+	content := []byte("test archive content")
+	sum := sha256.Sum256(content)
+	expectedDigest := "sha-256=" + base64.StdEncoding.EncodeToString(sum[:])
+	expectedContentDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+
 	// Create a test server
 	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "PUT" {
@@ -395,10 +1102,12 @@ func TestRegistryClient_Publish(t *testing.T) {
 			t.Errorf("expected 'application/zip', got %s", contentType)
 		}
 
-		// Check digest
-		digest := r.Header.Get("Digest")
-		if digest == "" {
-			t.Error("expected Digest header")
+		// Check digests are base64 encoded
+		if digest := r.Header.Get("Digest"); digest != expectedDigest {
+			t.Errorf("expected Digest %q, got %q", expectedDigest, digest)
+		}
+		if digest := r.Header.Get("Content-Digest"); digest != expectedContentDigest {
+			t.Errorf("expected Content-Digest %q, got %q", expectedContentDigest, digest)
 		}
 
 		w.WriteHeader(http.StatusCreated)
@@ -411,7 +1120,7 @@ func TestRegistryClient_Publish(t *testing.T) {
 		t.Fatalf("failed to create temp file: %v", err)
 	}
 	defer func() { _ = os.Remove(tempFile.Name()) }()
-	_, _ = tempFile.Write([]byte("test archive content"))
+	_, _ = tempFile.Write(content)
 	_ = tempFile.Close()
 
 	client := &RegistryClient{
@@ -420,7 +1129,7 @@ func TestRegistryClient_Publish(t *testing.T) {
 		httpClient: server.Client(),
 	}
 
-	err = client.Publish(context.Background(), "testorg", "TestPackage", "1.0.0", tempFile.Name(), "abc123checksum")
+	err = client.Publish(context.Background(), "testorg", "TestPackage", "1.0.0", tempFile.Name(), Digests{DigestSHA256: hex.EncodeToString(sum[:])})
 	if err != nil {
 		t.Fatalf("publish failed: %v", err)
 	}
@@ -484,3 +1193,313 @@ func TestRegistryClient_GetRelease(t *testing.T) {
 		})
 	}
 }
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected int64
+		ok       bool
+	}{
+		{input: 1024, expected: 1024, ok: true},
+		{input: float64(2048), expected: 2048, ok: true},
+		{input: "512", expected: 512, ok: true},
+		{input: "100MB", expected: 100 << 20, ok: true},
+		{input: "1.5 GiB", expected: 3 << 29, ok: true},
+		{input: "64k", expected: 64 << 10, ok: true},
+		{input: "lots", ok: false},
+		{input: nil, ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseByteSize(tt.input)
+		if ok != tt.ok {
+			t.Errorf("parseByteSize(%v) ok = %v, expected %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && got != tt.expected {
+			t.Errorf("parseByteSize(%v) = %d, expected %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSwiftPMPlugin_ParseConfig_SecretScan(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	cfg := p.parseConfig(map[string]any{})
+	if cfg.SecretScan.Enabled {
+		t.Error("expected secret scan disabled by default")
+	}
+	if cfg.SecretScan.AllowlistFile != ".secrets-allowlist" {
+		t.Errorf("expected default allowlist file, got %s", cfg.SecretScan.AllowlistFile)
+	}
+
+	cfg = p.parseConfig(map[string]any{
+		"secret_scan": map[string]any{
+			"enabled":        true,
+			"allowlist_file": "config/allowlist",
+		},
+	})
+	if !cfg.SecretScan.Enabled {
+		t.Error("expected secret scan enabled")
+	}
+	if cfg.SecretScan.AllowlistFile != "config/allowlist" {
+		t.Errorf("expected custom allowlist file, got %s", cfg.SecretScan.AllowlistFile)
+	}
+}
+
+func TestSwiftPMPlugin_Execute_APIBreakage(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	initTaggedRepo(t, tempDir, "v1.1.0", "v1.2.0")
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": "// swift-tools-version:5.9\nimport PackageDescription\n\nlet package = Package(name: \"Kit\")\n",
+	})
+
+	execute := func(releaseType string) *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.3.0", ReleaseType: releaseType},
+			Config: map[string]any{
+				"manifest_path": filepath.Join(tempDir, "Package.swift"),
+				"tag_prefix":    "v",
+				"validate":      false,
+				"build":         false,
+				"test":          false,
+				"api_breakage":  map[string]any{"enabled": true},
+			},
+			DryRun: true,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("PrePublish failed: %s", resp.Message)
+		}
+		return resp
+	}
+
+	if resp := execute("minor"); resp.Outputs["api_baseline"] != "v1.2.0" {
+		t.Errorf("api_baseline = %v, want v1.2.0", resp.Outputs["api_baseline"])
+	}
+	// Without a release type, the version is compared with the tag's
+	if resp := execute(""); resp.Outputs["api_baseline"] != "v1.2.0" {
+		t.Errorf("api_baseline without release type = %v, want v1.2.0", resp.Outputs["api_baseline"])
+	}
+	if resp := execute("major"); resp.Outputs["api_baseline"] != nil {
+		t.Errorf("major release should skip the check, got baseline %v", resp.Outputs["api_baseline"])
+	}
+}
+
+func TestSwiftPMPlugin_Execute_ManifestCompatibility(t *testing.T) {
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{"Package.swift": compatPreviousManifest})
+	initTaggedRepo(t, tempDir, "v1.4.0")
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": strings.Replace(compatPreviousManifest, ".macOS(.v12)", ".macOS(.v13)", 1),
+	})
+
+	config := map[string]any{
+		"manifest_path":          filepath.Join(tempDir, "Package.swift"),
+		"tag_prefix":             "v",
+		"validate":               false,
+		"build":                  false,
+		"test":                   false,
+		"manifest_compatibility": map[string]any{"enabled": true},
+	}
+	execute := func(releaseType string) *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.5.0", ReleaseType: releaseType},
+			Config:  config,
+			DryRun:  true,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := execute("minor")
+	if resp.Success || !strings.Contains(resp.Message, "Package.swift changes since v1.4.0 need a major release: platform macos minimum changed from 12.0 to 13.0") {
+		t.Errorf("expected raised platform minimum to fail a minor release, got %+v", resp)
+	}
+
+	// Without a release type, 1.5.0 is compared with v1.4.0
+	if resp := execute(""); resp.Success {
+		t.Errorf("expected raised platform minimum to fail a release without a release type")
+	}
+
+	resp = execute("major")
+	if !resp.Success {
+		t.Fatalf("major release should pass: %s", resp.Message)
+	}
+	changes, _ := resp.Outputs["manifest_changes"].([]ManifestChange)
+	if len(changes) != 1 || !changes[0].Breaking {
+		t.Errorf("manifest_changes = %+v", resp.Outputs["manifest_changes"])
+	}
+
+	config["manifest_compatibility"] = map[string]any{"enabled": true, "allow": []any{"macos"}}
+	if resp := execute("minor"); !resp.Success {
+		t.Errorf("allowed platform change should pass: %s", resp.Message)
+	}
+
+}
+
+// hideSwift removes the Swift toolchain from PATH, keeping git.
+func hideSwift(t *testing.T) {
+	t.Helper()
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(git, filepath.Join(dir, "git")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestSwiftPMPlugin_Execute_ManifestCompatibility_Partial(t *testing.T) {
+	hideSwift(t)
+	p := &SwiftPMPlugin{}
+
+	// The previous release computes the executable's name, and the
+	// current one removes KitLegacy
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": strings.Replace(compatPreviousManifest, `name: "kit"`, `name: "\(cli)"`, 1),
+	})
+	initTaggedRepo(t, tempDir, "v1.4.0")
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": strings.Replace(compatPreviousManifest, `        .library(name: "KitLegacy", targets: ["KitLegacy"]),
+`, "", 1),
+	})
+
+	config := map[string]any{
+		"manifest_path":          filepath.Join(tempDir, "Package.swift"),
+		"tag_prefix":             "v",
+		"validate":               false,
+		"build":                  false,
+		"test":                   false,
+		"manifest_compatibility": map[string]any{"enabled": true},
+	}
+	execute := func() *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.5.0", ReleaseType: "minor"},
+			Config:  config,
+			DryRun:  true,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := execute()
+	if resp.Success || !strings.Contains(resp.Message, "Package.swift could not be read completely to compare with v1.4.0") ||
+		!strings.Contains(resp.Message, "manifest_compatibility.allow_partial") {
+		t.Errorf("expected a partially read manifest to fail, got %+v", resp)
+	}
+
+	// A computed product name is not read, which must not look like a removal
+	config["manifest_compatibility"] = map[string]any{"enabled": true, "allow_partial": true}
+	resp = execute()
+	if !resp.Success {
+		t.Errorf("allow_partial should skip the check: %s", resp.Message)
+	}
+	if resp.Outputs["manifest_changes"] != nil {
+		t.Errorf("partially read manifest should not be compared, got %+v", resp.Outputs["manifest_changes"])
+	}
+}
+
+func TestSwiftPMPlugin_Execute_Lint(t *testing.T) {
+	p := &SwiftPMPlugin{}
+	if cfg := p.parseConfig(map[string]any{}); cfg.Lint.Enabled {
+		t.Error("expected lint disabled by default")
+	}
+
+	tempDir := t.TempDir()
+	source, err := os.ReadFile(filepath.Join("testdata", "package-swift", "literal.swift"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, tempDir, map[string]string{"Package.swift": string(source)})
+
+	config := map[string]any{
+		"manifest_path": filepath.Join(tempDir, "Package.swift"),
+		"validate":      false,
+		"build":         false,
+		"test":          false,
+		"lint":          map[string]any{"enabled": true},
+	}
+	execute := func() *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Context: plugin.ReleaseContext{Version: "1.0.0"},
+			Config:  config,
+			DryRun:  true,
+		})
+		if err != nil {
+			t.Fatalf("PrePublish failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := execute()
+	if resp.Success || !strings.Contains(resp.Message, "Package.swift lint failed: unsafe_flags: target Networking uses unsafeFlags") {
+		t.Errorf("expected unsafeFlags to fail PrePublish, got %+v", resp)
+	}
+	if findings, _ := resp.Outputs["lint_findings"].([]LintFinding); len(findings) != 1 {
+		t.Errorf("lint_findings = %+v", resp.Outputs["lint_findings"])
+	}
+
+	config["lint"] = map[string]any{"enabled": true, "rules": map[string]any{"unsafe_flags": "warning"}}
+	if resp := execute(); !resp.Success {
+		t.Errorf("lint warnings should not fail PrePublish: %s", resp.Message)
+	}
+}
+
+func TestSwiftPMPlugin_Execute_TestResults(t *testing.T) {
+	fakeSwift(t, fakeSwiftTest)
+	p := &SwiftPMPlugin{}
+
+	tempDir := t.TempDir()
+	writeTestFiles(t, tempDir, map[string]string{
+		"Package.swift": "// swift-tools-version:5.9\nimport PackageDescription\n\nlet package = Package(name: \"Kit\")\n",
+	})
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPrePublish,
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+		Config: map[string]any{
+			"manifest_path": filepath.Join(tempDir, "Package.swift"),
+			"validate":      false,
+			"build":         false,
+			"test_config":   map[string]any{"report_path": "reports/junit.xml", "max_failures": 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrePublish failed: %v", err)
+	}
+
+	want := "Tests failed: 2 of 5 tests failed\n" +
+		`  KitTests.ClientTests.testTimeout (ClientTests.swift:42): XCTAssertEqual failed: ("408") is not equal to ("200")` + "\n" +
+		"  ... and 1 more"
+	if resp.Success || resp.Message != want {
+		t.Errorf("message = %q, want %q", resp.Message, want)
+	}
+	if summary, _ := resp.Outputs["tests"].(*TestSummary); summary == nil || summary.Failed != 2 {
+		t.Errorf("tests output = %+v", resp.Outputs["tests"])
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "reports", "junit.xml")); err != nil {
+		t.Errorf("JUnit report not written: %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PodspecConfig keeps companion CocoaPods podspecs in step with the release.
+type PodspecConfig struct {
+	Enabled bool `json:"enabled"`
+	// Paths are slash-separated globs of podspecs relative to the package
+	// root; defaults to *.podspec and *.podspec.json.
+	Paths []string `json:"paths"`
+	// Check compares swift_version and platform deployment targets with
+	// Package.swift.
+	Check bool `json:"check"`
+}
+
+// defaultPodspecPaths are the podspecs looked for next to Package.swift.
+var defaultPodspecPaths = []string{"*.podspec", "*.podspec.json"}
+
+// validate reports a configuration problem, or "".
+func (c PodspecConfig) validate() string {
+	for _, glob := range c.Paths {
+		if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+			return fmt.Sprintf("invalid path glob %q", glob)
+		}
+		if !strings.HasSuffix(glob, ".podspec") && !strings.HasSuffix(glob, ".podspec.json") {
+			return fmt.Sprintf("path %q must match .podspec or .podspec.json files", glob)
+		}
+	}
+	return ""
+}
+
+// Podspec describes a podspec updated for the release.
+type Podspec struct {
+	// File is the slash-separated path relative to the package root.
+	File string `json:"file"`
+	// SwiftVersions are the declared swift_version or swift_versions.
+	SwiftVersions []string `json:"swift_versions,omitempty"`
+	// Platforms maps SwiftPM platform names, such as ios and macos, to the
+	// podspec's deployment targets.
+	Platforms    map[string]string    `json:"platforms,omitempty"`
+	Replacements []VersionReplacement `json:"replacements"`
+}
+
+// cocoaPodsPlatforms maps CocoaPods platform names to SwiftPM ones.
+var cocoaPodsPlatforms = map[string]string{
+	"ios":      "ios",
+	"osx":      "macos",
+	"macos":    "macos",
+	"tvos":     "tvos",
+	"watchos":  "watchos",
+	"visionos": "visionos",
+}
+
+var (
+	// s.version = '1.2.3'
+	rubyPodspecVersion = regexp.MustCompile(`(\b\w+\.version\s*=\s*)(?:'([^'\n]*)'|"([^"\n]*)")`)
+	// :tag => 'v1.2.3' or tag: "v#{s.version}"
+	rubyPodspecTag = regexp.MustCompile(`((?::tag\s*=>|\btag:)\s*)(?:'([^'\n]*)'|"([^"\n]*)")`)
+	// s.swift_version = '5.9' or s.swift_versions = ['5.9', '6.0']
+	rubyPodspecSwiftVersions = regexp.MustCompile(`\b\w+\.swift_versions?\s*=\s*([^\n]+)`)
+	// s.platform = :ios, '13.0'
+	rubyPodspecPlatform = regexp.MustCompile(`\b\w+\.platform\s*=\s*:(\w+)\s*,\s*['"]([^'"\n]+)['"]`)
+	// s.platforms = { :ios => '13.0', :osx => '10.15' }
+	rubyPodspecPlatforms = regexp.MustCompile(`\b\w+\.platforms\s*=\s*\{([^}]*)\}`)
+	// s.ios.deployment_target = '13.0'
+	rubyPodspecDeploymentTarget = regexp.MustCompile(`\b\w+\.(\w+)\.deployment_target\s*=\s*['"]([^'"\n]+)['"]`)
+	// :ios => '13.0', ios: '13.0' or 'ios' => '13.0' inside a hash
+	rubyHashEntry = regexp.MustCompile(`['":]?(\w+)['"]?\s*(?:=>|:)\s*['"]([^'"\n]+)['"]`)
+	// "1.2.3" or '1.2.3'
+	rubyStringLiteral = regexp.MustCompile(`'([^'\n]*)'|"([^"\n]*)"`)
+	// #{s.version} or #{spec.version.to_s}
+	rubyVersionInterpolation = regexp.MustCompile(`#\{\s*\w+\.version(?:\.to_s)?\s*\}`)
+
+	// "version": "1.2.3" and "tag": "1.2.3" in a JSON podspec
+	jsonPodspecVersion = regexp.MustCompile(`("version"\s*:\s*)"((?:[^"\\\n]|\\.)*)"`)
+	jsonPodspecTag     = regexp.MustCompile(`("tag"\s*:\s*)"((?:[^"\\\n]|\\.)*)"`)
+)
+
+// UpdatePodspecs sets the version and source tag of every podspec under
+// workDir matching the configured globs. A podspec must declare a literal
+// version; a tag interpolating the version is kept when it already produces
+// the release tag. When manifest is set, each podspec is checked against it
+// with CheckPodspec. Files are only written when write is set and every
+// podspec was updated and checked successfully.
+func UpdatePodspecs(workDir string, cfg PodspecConfig, data templateData, manifest *PackageManifest, write bool) ([]Podspec, error) {
+	globs := cfg.Paths
+	if len(globs) == 0 {
+		globs = defaultPodspecPaths
+	}
+	var files []string
+	for _, glob := range globs {
+		matches, err := globFiles(workDir, glob)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range matches {
+			if !slices.Contains(files, file) {
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no podspec matches %s", strings.Join(globs, ", "))
+	}
+
+	var specs []Podspec
+	var problems []string
+	edits := newFileEdits(workDir)
+	for _, file := range files {
+		content, err := edits.read(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var spec Podspec
+		if strings.HasSuffix(file, ".json") {
+			content, spec, err = updateJSONPodspec(content, data)
+		} else {
+			content, spec, err = updateRubyPodspec(content, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		spec.File = file
+		for i := range spec.Replacements {
+			spec.Replacements[i].File = file
+		}
+		specs = append(specs, spec)
+		edits.set(file, content)
+
+		if manifest != nil {
+			for _, problem := range CheckPodspec(spec, manifest) {
+				problems = append(problems, file+": "+problem)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("podspec does not match Package.swift: %s", strings.Join(problems, "; "))
+	}
+
+	if write {
+		if err := edits.write(); err != nil {
+			return nil, err
+		}
+	}
+	return specs, nil
+}
+
+// updateRubyPodspec rewrites a Ruby podspec and reads its Swift versions
+// and platforms.
+func updateRubyPodspec(content []byte, data templateData) ([]byte, Podspec, error) {
+	var spec Podspec
+	content, replacements, err := replaceRubyString(content, rubyPodspecVersion, "version", data.Version, data.Version)
+	if err != nil {
+		return nil, spec, err
+	}
+	if len(replacements) == 0 {
+		return nil, spec, fmt.Errorf("no version string found")
+	}
+	spec.Replacements = replacements
+
+	content, replacements, err = replaceRubyString(content, rubyPodspecTag, "tag", data.Tag, data.Version)
+	if err != nil {
+		return nil, spec, err
+	}
+	spec.Replacements = append(spec.Replacements, replacements...)
+
+	for _, m := range rubyPodspecSwiftVersions.FindAllSubmatch(content, -1) {
+		for _, s := range rubyStringLiteral.FindAllSubmatch(m[1], -1) {
+			spec.SwiftVersions = append(spec.SwiftVersions, string(s[1])+string(s[2]))
+		}
+	}
+
+	platforms := make(map[string]string)
+	for _, m := range rubyPodspecPlatform.FindAllSubmatch(content, -1) {
+		addPodspecPlatform(platforms, string(m[1]), string(m[2]))
+	}
+	for _, m := range rubyPodspecPlatforms.FindAllSubmatch(content, -1) {
+		for _, entry := range rubyHashEntry.FindAllSubmatch(m[1], -1) {
+			addPodspecPlatform(platforms, string(entry[1]), string(entry[2]))
+		}
+	}
+	for _, m := range rubyPodspecDeploymentTarget.FindAllSubmatch(content, -1) {
+		addPodspecPlatform(platforms, string(m[1]), string(m[2]))
+	}
+	if len(platforms) > 0 {
+		spec.Platforms = platforms
+	}
+	return content, spec, nil
+}
+
+// replaceRubyString sets every string literal matched by pattern to value,
+// keeping its quotes. A double-quoted string interpolating the podspec's
+// version is left alone if it already evaluates to value.
+func replaceRubyString(content []byte, pattern *regexp.Regexp, name, value, version string) ([]byte, []VersionReplacement, error) {
+	var replacements []VersionReplacement
+	var out bytes.Buffer
+	last := 0
+	for _, m := range pattern.FindAllSubmatchIndex(content, -1) {
+		quote, start, end := `'`, m[4], m[5]
+		if start < 0 {
+			quote, start, end = `"`, m[6], m[7]
+		}
+		old := string(content[start:end])
+
+		if quote == `"` && strings.Contains(old, "#{") {
+			resolved := rubyVersionInterpolation.ReplaceAllLiteralString(old, version)
+			if strings.Contains(resolved, "#{") {
+				continue
+			}
+			if resolved != value {
+				return nil, nil, fmt.Errorf("line %d: %s %q resolves to %q, want %q", lineAt(content, start), name, old, resolved, value)
+			}
+			continue
+		}
+
+		out.Write(content[last:start])
+		out.WriteString(rubyStringContent(value, quote))
+		last = end
+		replacements = append(replacements, VersionReplacement{
+			Line: lineAt(content, start),
+			Name: name,
+			Old:  quote + old + quote,
+			New:  quote + rubyStringContent(value, quote) + quote,
+		})
+	}
+	out.Write(content[last:])
+	return out.Bytes(), replacements, nil
+}
+
+// rubyStringContent escapes s for a Ruby string literal with the quote.
+func rubyStringContent(s, quote string) string {
+	if quote == `'` {
+		return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `#{`, `\#{`).Replace(s)
+}
+
+// jsonPodspec holds the fields of a JSON podspec the plugin reads.
+type jsonPodspec struct {
+	Version       string            `json:"version"`
+	SwiftVersion  json.RawMessage   `json:"swift_version"`
+	SwiftVersions json.RawMessage   `json:"swift_versions"`
+	Platforms     map[string]string `json:"platforms"`
+}
+
+// updateJSONPodspec rewrites a JSON podspec and reads its Swift versions
+// and platforms. The file is edited as text to keep its formatting.
+func updateJSONPodspec(content []byte, data templateData) ([]byte, Podspec, error) {
+	var spec Podspec
+	var parsed jsonPodspec
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		return nil, spec, fmt.Errorf("invalid podspec JSON: %w", err)
+	}
+	if parsed.Version == "" {
+		return nil, spec, fmt.Errorf("no version found")
+	}
+
+	for _, raw := range []json.RawMessage{parsed.SwiftVersion, parsed.SwiftVersions} {
+		var one string
+		var many []string
+		if json.Unmarshal(raw, &one) == nil && one != "" {
+			spec.SwiftVersions = append(spec.SwiftVersions, one)
+		} else if json.Unmarshal(raw, &many) == nil {
+			spec.SwiftVersions = append(spec.SwiftVersions, many...)
+		}
+	}
+	platforms := make(map[string]string)
+	for name, target := range parsed.Platforms {
+		addPodspecPlatform(platforms, name, target)
+	}
+	if len(platforms) > 0 {
+		spec.Platforms = platforms
+	}
+
+	for _, field := range []struct {
+		name    string
+		pattern *regexp.Regexp
+		value   string
+	}{
+		{"version", jsonPodspecVersion, data.Version},
+		{"tag", jsonPodspecTag, data.Tag},
+	} {
+		quoted, _ := json.Marshal(field.value)
+		var out bytes.Buffer
+		last := 0
+		for _, m := range field.pattern.FindAllSubmatchIndex(content, -1) {
+			old := string(content[m[4]-1 : m[5]+1])
+			out.Write(content[last : m[4]-1])
+			out.Write(quoted)
+			last = m[5] + 1
+			spec.Replacements = append(spec.Replacements, VersionReplacement{
+				Line: lineAt(content, m[4]),
+				Name: field.name,
+				Old:  old,
+				New:  string(quoted),
+			})
+		}
+		out.Write(content[last:])
+		content = out.Bytes()
+	}
+	return content, spec, nil
+}
+
+// addPodspecPlatform records a deployment target under its SwiftPM name.
+// Unknown platforms are ignored.
+func addPodspecPlatform(platforms map[string]string, name, target string) {
+	if platform, ok := cocoaPodsPlatforms[strings.ToLower(name)]; ok {
+		platforms[platform] = target
+	}
+}
+
+// CheckPodspec compares a podspec with the package manifest and returns the
+// problems found: Swift versions outside the manifest's language modes, and
+// deployment targets that differ from its platform minimums.
+func CheckPodspec(spec Podspec, manifest *PackageManifest) []string {
+	var problems []string
+
+	// The default language mode is the tools version's major version
+	modes := make([]string, 0, len(manifest.SwiftLanguageVersions))
+	for _, mode := range manifest.SwiftLanguageVersions {
+		modes = append(modes, majorVersion(string(mode)))
+	}
+	if len(modes) == 0 && manifest.ToolsVersion.Version != "" {
+		modes = append(modes, majorVersion(manifest.ToolsVersion.Version))
+	}
+	for _, version := range spec.SwiftVersions {
+		if len(modes) > 0 && !slices.Contains(modes, majorVersion(version)) {
+			problems = append(problems, fmt.Sprintf("swift_version %s does not match Package.swift Swift language modes %s",
+				version, strings.Join(modes, ", ")))
+		}
+	}
+
+	for _, platform := range manifest.Platforms {
+		target, ok := spec.Platforms[platform.Name]
+		if !ok || platform.Version == "" {
+			continue
+		}
+		if compareDottedVersions(target, platform.Version) != 0 {
+			problems = append(problems, fmt.Sprintf("%s deployment target %s does not match Package.swift minimum %s",
+				platform.Name, target, platform.Version))
+		}
+	}
+	return problems
+}
+
+// majorVersion returns the first component of a dotted version.
+func majorVersion(v string) string {
+	major, _, _ := strings.Cut(strings.TrimSpace(v), ".")
+	return major
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPodspec = `Pod::Spec.new do |s|
+  s.name         = 'Kit'
+  s.version      = '1.0.0'
+  s.summary      = 'A kit.'
+  s.source       = { :git => 'https://github.com/org/kit.git', :tag => "v#{s.version}" }
+  s.swift_versions = ['5.9', '5.10']
+  s.ios.deployment_target = '13.0'
+  s.osx.deployment_target = '10.15'
+  s.source_files = 'Sources/Kit/**/*.swift'
+end
+`
+
+const testPodspecJSON = `{
+  "name": "KitCore",
+  "version": "1.0.0",
+  "source": {
+    "git": "https://github.com/org/kit.git",
+    "tag": "1.0.0"
+  },
+  "swift_version": "5.9",
+  "platforms": {
+    "ios": "13.0",
+    "osx": "10.15"
+  }
+}
+`
+
+// testPodspecManifest declares the platforms and language mode the podspecs
+// above agree with.
+var testPodspecManifest = &PackageManifest{
+	Name:         "Kit",
+	ToolsVersion: ToolsVersion{Version: "5.9.0"},
+	Platforms: []Platform{
+		{Name: "ios", Version: "13.0"},
+		{Name: "macos", Version: "10.15"},
+	},
+}
+
+func TestUpdatePodspecs(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Kit.podspec":          testPodspec,
+		"KitCore.podspec.json": testPodspecJSON,
+		"Example/Kit.podspec":  testPodspec,
+	})
+
+	specs, err := UpdatePodspecs(dir, PodspecConfig{}, newTemplateData("1.4.0", "v"), testPodspecManifest, true)
+	if err != nil {
+		t.Fatalf("UpdatePodspecs() error = %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected the 2 root podspecs, got %+v", specs)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "Kit.podspec"))
+	if want := strings.Replace(testPodspec, "'1.0.0'", "'1.4.0'", 1); string(content) != want {
+		t.Errorf("Kit.podspec =\n%s", content)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, "KitCore.podspec.json"))
+	if want := strings.Replace(strings.Replace(testPodspecJSON, `"version": "1.0.0"`, `"version": "1.4.0"`, 1), `"tag": "1.0.0"`, `"tag": "v1.4.0"`, 1); string(content) != want {
+		t.Errorf("KitCore.podspec.json =\n%s", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "Example", "Kit.podspec")); string(content) != testPodspec {
+		t.Error("podspec outside the default paths was modified")
+	}
+
+	ruby := specs[0]
+	if ruby.File != "Kit.podspec" || len(ruby.Replacements) != 1 ||
+		ruby.Replacements[0] != (VersionReplacement{File: "Kit.podspec", Line: 3, Name: "version", Old: "'1.0.0'", New: "'1.4.0'"}) {
+		t.Errorf("unexpected Ruby podspec %+v", ruby)
+	}
+	if !reflect.DeepEqual(ruby.SwiftVersions, []string{"5.9", "5.10"}) ||
+		!reflect.DeepEqual(ruby.Platforms, map[string]string{"ios": "13.0", "macos": "10.15"}) {
+		t.Errorf("unexpected Ruby podspec settings %+v", ruby)
+	}
+
+	json := specs[1]
+	if len(json.Replacements) != 2 || json.Replacements[1] != (VersionReplacement{File: "KitCore.podspec.json", Line: 6, Name: "tag", Old: `"1.0.0"`, New: `"v1.4.0"`}) {
+		t.Errorf("unexpected JSON podspec replacements %+v", json.Replacements)
+	}
+	if !reflect.DeepEqual(json.SwiftVersions, []string{"5.9"}) ||
+		!reflect.DeepEqual(json.Platforms, map[string]string{"ios": "13.0", "macos": "10.15"}) {
+		t.Errorf("unexpected JSON podspec settings %+v", json)
+	}
+}
+
+func TestUpdatePodspecs_Tags(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{"literal hash rocket", `s.source = { :git => 'x', :tag => '1.0.0' }`, "v", `s.source = { :git => 'x', :tag => 'v2.0.0' }`, ""},
+		{"literal keyword", `s.source = { git: "x", tag: "1.0.0" }`, "", `s.source = { git: "x", tag: "2.0.0" }`, ""},
+		{"interpolated", `s.source = { :git => 'x', :tag => "#{s.version.to_s}" }`, "", `s.source = { :git => 'x', :tag => "#{s.version.to_s}" }`, ""},
+		{"interpolated with other prefix", `s.source = { :git => 'x', :tag => "v#{s.version}" }`, "", "", `resolves to "v2.0.0", want "2.0.0"`},
+		{"single quotes do not interpolate", `s.source = { :git => 'x', :tag => '#{s.version}' }`, "v", `s.source = { :git => 'x', :tag => 'v2.0.0' }`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			original := "Pod::Spec.new do |s|\n  s.version = \"1.0.0\"\n  " + tt.source + "\nend\n"
+			writeTestFiles(t, dir, map[string]string{"Kit.podspec": original})
+
+			_, err := UpdatePodspecs(dir, PodspecConfig{}, newTemplateData("2.0.0", tt.prefix), nil, true)
+			content, _ := os.ReadFile(filepath.Join(dir, "Kit.podspec"))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if string(content) != original {
+					t.Errorf("podspec modified despite error:\n%s", content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdatePodspecs() error = %v", err)
+			}
+			if want := "Pod::Spec.new do |s|\n  s.version = \"2.0.0\"\n  " + tt.want + "\nend\n"; string(content) != want {
+				t.Errorf("podspec =\n%s\nwant\n%s", content, want)
+			}
+		})
+	}
+}
+
+func TestUpdatePodspecs_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"no podspec", map[string]string{"Package.swift": ""}, "no podspec matches *.podspec, *.podspec.json"},
+		{"no version", map[string]string{"Kit.podspec": "Pod::Spec.new do |s|\n  s.version = VERSION\nend\n"}, "Kit.podspec: no version string found"},
+		{"invalid json", map[string]string{"Kit.podspec.json": "{"}, "invalid podspec JSON"},
+		{"mismatched platform", map[string]string{"Kit.podspec": strings.Replace(testPodspec, "'13.0'", "'12.0'", 1)},
+			"Kit.podspec: ios deployment target 12.0 does not match Package.swift minimum 13.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, tt.files)
+
+			_, err := UpdatePodspecs(dir, PodspecConfig{}, newTemplateData("2.0.0", "v"), testPodspecManifest, true)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			for name, original := range tt.files {
+				if content, _ := os.ReadFile(filepath.Join(dir, name)); string(content) != original {
+					t.Errorf("%s modified despite error:\n%s", name, content)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPodspec(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     Podspec
+		manifest PackageManifest
+		want     []string
+	}{
+		{
+			name:     "matching",
+			spec:     Podspec{SwiftVersions: []string{"5.9"}, Platforms: map[string]string{"ios": "13", "watchos": "8.0"}},
+			manifest: PackageManifest{ToolsVersion: ToolsVersion{Version: "5.9.0"}, Platforms: []Platform{{Name: "ios", Version: "13.0"}}},
+		},
+		{
+			name:     "tools version language mode",
+			spec:     Podspec{SwiftVersions: []string{"5.9"}},
+			manifest: PackageManifest{ToolsVersion: ToolsVersion{Version: "6.0.0"}},
+			want:     []string{"swift_version 5.9 does not match Package.swift Swift language modes 6"},
+		},
+		{
+			name:     "declared language modes",
+			spec:     Podspec{SwiftVersions: []string{"5.10", "6.0"}},
+			manifest: PackageManifest{ToolsVersion: ToolsVersion{Version: "6.0.0"}, SwiftLanguageVersions: []SwiftLanguageVersion{"5", "6"}},
+		},
+		{
+			name:     "platform minimums",
+			spec:     Podspec{Platforms: map[string]string{"ios": "15.0", "macos": "12.0"}},
+			manifest: PackageManifest{Platforms: []Platform{{Name: "ios", Version: "13.0"}, {Name: "macos", Version: "12.0"}, {Name: "tvos", Version: "13.0"}}},
+			want:     []string{"ios deployment target 15.0 does not match Package.swift minimum 13.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPodspec(tt.spec, &tt.manifest); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckPodspec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodspecConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  PodspecConfig
+		want string
+	}{
+		{"defaults", PodspecConfig{Enabled: true}, ""},
+		{"paths", PodspecConfig{Enabled: true, Paths: []string{"Kit.podspec", "Specs/*.podspec.json"}}, ""},
+		{"not a podspec", PodspecConfig{Enabled: true, Paths: []string{"Package.swift"}}, "must match"},
+		{"bad glob", PodspecConfig{Enabled: true, Paths: []string{"[.podspec"}}, "invalid path glob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.validate()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
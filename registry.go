@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 )
@@ -102,8 +104,9 @@ func (c *RegistryClient) GetRelease(ctx context.Context, scope, name, version st
 	return &Release{Version: version}, nil
 }
 
-// Publish publishes a package version to the registry.
-func (c *RegistryClient) Publish(ctx context.Context, scope, name, version, archivePath, checksum string) error {
+// Publish publishes a package version to the registry. The archive digests
+// are sent base64 encoded in the Digest and Content-Digest headers.
+func (c *RegistryClient) Publish(ctx context.Context, scope, name, version, archivePath string, digests Digests) error {
 	endpoint := fmt.Sprintf("/%s/%s/%s", scope, name, version)
 
 	file, err := os.Open(archivePath)
@@ -118,6 +121,15 @@ func (c *RegistryClient) Publish(ctx context.Context, scope, name, version, arch
 		return fmt.Errorf("failed to stat archive: %w", err)
 	}
 
+	digestHeader, err := digests.DigestHeader()
+	if err != nil {
+		return err
+	}
+	contentDigestHeader, err := digests.ContentDigestHeader()
+	if err != nil {
+		return err
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+endpoint, file)
 	if err != nil {
 		return err
@@ -127,7 +139,8 @@ func (c *RegistryClient) Publish(ctx context.Context, scope, name, version, arch
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/zip")
 	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
-	req.Header.Set("Digest", "sha-256="+checksum)
+	req.Header.Set("Digest", digestHeader)
+	req.Header.Set("Content-Digest", contentDigestHeader)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -182,3 +195,41 @@ func (c *RegistryClient) GetManifest(ctx context.Context, scope, name, version s
 
 	return string(body), nil
 }
+
+// LookupIdentifiers returns the registry identifiers (scope.name) of the
+// package at a source control URL. It returns nil when the registry knows
+// none.
+func (c *RegistryClient) LookupIdentifiers(ctx context.Context, repositoryURL string) ([]string, error) {
+	endpoint := "/identifiers?url=" + url.QueryEscape(repositoryURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.swift.registry.v1+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to look up identifiers: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Identifiers []string `json:"identifiers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode identifiers: %w", err)
+	}
+	return result.Identifiers, nil
+}
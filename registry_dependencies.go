@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RegistryDependenciesConfig rewrites source control dependencies to
+// registry identifiers in the archived Package.swift, so consumers resolve
+// them through the registry too. The working tree is never modified.
+type RegistryDependenciesConfig struct {
+	Enabled bool `json:"enabled"`
+	// Identifiers maps repository URLs to scope.name registry identifiers.
+	Identifiers map[string]string `json:"identifiers"`
+	// Lookup asks the registry's identifiers endpoint about URLs without a
+	// mapping.
+	Lookup bool `json:"lookup"`
+}
+
+// registryIdentifier matches a scope.name package identifier.
+var registryIdentifier = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,38}\.[A-Za-z0-9][A-Za-z0-9_-]{0,99}$`)
+
+// validate reports a configuration problem, or "".
+func (c RegistryDependenciesConfig) validate() string {
+	if !c.Enabled {
+		return ""
+	}
+	if len(c.Identifiers) == 0 && !c.Lookup {
+		return "requires identifiers or lookup"
+	}
+	for url, id := range c.Identifiers {
+		if !registryIdentifier.MatchString(id) {
+			return fmt.Sprintf("identifier %q for %s is not a scope.name identifier", id, url)
+		}
+	}
+	return ""
+}
+
+// RegistryDependencyRewrite records a dependency moved to the registry.
+type RegistryDependencyRewrite struct {
+	// Manifest is the name of the rewritten manifest, such as
+	// Package@swift-5.9.swift. RewriteRegistryDependencies leaves it empty.
+	Manifest string `json:"manifest,omitempty"`
+	Line     int    `json:"line"`
+	URL      string `json:"url"`
+	ID       string `json:"id"`
+}
+
+// identifierLookup returns the registry identifiers of a repository URL.
+type identifierLookup func(ctx context.Context, repositoryURL string) ([]string, error)
+
+var (
+	packageCall   = regexp.MustCompile(`\.package\s*\(`)
+	packageURLArg = regexp.MustCompile(`\burl\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	// name: "Kit", as the first argument of .package(name:url:)
+	packageNameArg = regexp.MustCompile(`^\s*name\s*:\s*"((?:[^"\\]|\\.)*)"\s*,\s*`)
+	// Registries only serve versions, not branches or revisions
+	packageUnversionedArg = regexp.MustCompile(`\b(?:branch|revision)\s*:|\.(?:branch|revision)\s*\(`)
+	// package: "kit" in .product(name:package:)
+	productPackageArg = regexp.MustCompile(`(\bpackage\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+)
+
+// RewriteRegistryDependencies replaces .package(url:...) dependencies whose
+// URL has a registry identifier with .package(id:...), keeping their
+// requirement, and points .product(name:package:) references at the new
+// identities. Identifiers come from the configured mapping, then from
+// lookup when it is set. Branch and revision dependencies and URLs without
+// an identifier are left alone. Registry dependencies need
+// swift-tools-version 5.7 or later.
+func RewriteRegistryDependencies(ctx context.Context, content []byte, cfg RegistryDependenciesConfig, lookup identifierLookup) ([]byte, []RegistryDependencyRewrite, error) {
+	identifiers := make(map[string]string, len(cfg.Identifiers))
+	for url, id := range cfg.Identifiers {
+		identifiers[normalizeRepositoryURL(url)] = id
+	}
+	resolve := func(url string) (string, error) {
+		key := normalizeRepositoryURL(url)
+		if id, ok := identifiers[key]; ok || lookup == nil {
+			return id, nil
+		}
+		ids, err := lookup(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		if len(ids) > 1 {
+			return "", fmt.Errorf("registry has several identifiers for %s (%s); map it in identifiers", url, strings.Join(ids, ", "))
+		}
+		identifiers[key] = ""
+		if len(ids) == 1 {
+			identifiers[key] = ids[0]
+		}
+		return identifiers[key], nil
+	}
+
+	var rewrites []RegistryDependencyRewrite
+	// Old package identities and names, lowercased, to their registry ids
+	renamed := make(map[string]string)
+	var out bytes.Buffer
+	last := 0
+	for _, loc := range packageCall.FindAllIndex(content, -1) {
+		start := loc[1]
+		end := closingParen(content, start)
+		if end < 0 {
+			continue
+		}
+		args := content[start:end]
+		url := packageURLArg.FindSubmatchIndex(args)
+		if url == nil || packageUnversionedArg.Match(args) {
+			continue
+		}
+		location := string(args[url[2]:url[3]])
+		id, err := resolve(location)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id == "" {
+			continue
+		}
+
+		rewritten := make([]byte, 0, len(args))
+		rewritten = append(rewritten, args[:url[0]]...)
+		rewritten = append(rewritten, fmt.Sprintf("id: %q", id)...)
+		rewritten = append(rewritten, args[url[1]:]...)
+		if name := packageNameArg.FindSubmatchIndex(rewritten); name != nil {
+			renamed[strings.ToLower(string(rewritten[name[2]:name[3]]))] = id
+			rewritten = append(rewritten[:name[0]:name[0]], rewritten[name[1]:]...)
+		}
+		renamed[identityFromLocation(location)] = id
+
+		out.Write(content[last:start])
+		out.Write(rewritten)
+		last = end
+		rewrites = append(rewrites, RegistryDependencyRewrite{
+			Line: lineAt(content, loc[0]),
+			URL:  location,
+			ID:   id,
+		})
+	}
+	out.Write(content[last:])
+	if len(rewrites) == 0 {
+		return content, nil, nil
+	}
+
+	if m := swiftToolsVersionPattern.FindSubmatch(content); m == nil || compareDottedVersions(string(m[1]), "5.7") < 0 {
+		return nil, nil, fmt.Errorf("registry dependencies require swift-tools-version 5.7 or later")
+	}
+
+	result := productPackageArg.ReplaceAllFunc(out.Bytes(), func(arg []byte) []byte {
+		m := productPackageArg.FindSubmatch(arg)
+		if id, ok := renamed[strings.ToLower(string(m[2]))]; ok {
+			return []byte(string(m[1]) + id + string(m[3]))
+		}
+		return arg
+	})
+	return result, rewrites, nil
+}
+
+// normalizeRepositoryURL lowercases a repository URL and drops a trailing
+// slash and .git suffix, so equivalent spellings compare equal.
+func normalizeRepositoryURL(url string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimRight(url, "/"), ".git"))
+}
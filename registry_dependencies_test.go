@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const registryManifest = `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-argument-parser.git", from: "1.2.0"),
+        .package(name: "LinkedList", url: "https://github.com/mona/LinkedList", .upToNextMinor(from: "1.1.0")),
+        .package(url: "https://github.com/jpsim/Yams", branch: "main"),
+        .package(url: "https://github.com/org/unpublished", exact: "0.3.0"),
+    ],
+    targets: [
+        .target(name: "Kit", dependencies: [
+            .product(name: "ArgumentParser", package: "swift-argument-parser"),
+            .product(name: "LinkedList", package: "LinkedList"),
+            .product(name: "Yams", package: "Yams"),
+        ]),
+    ]
+)
+`
+
+const registryManifestRewritten = `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Kit",
+    dependencies: [
+        .package(id: "apple.swift-argument-parser", from: "1.2.0"),
+        .package(id: "mona.LinkedList", .upToNextMinor(from: "1.1.0")),
+        .package(url: "https://github.com/jpsim/Yams", branch: "main"),
+        .package(url: "https://github.com/org/unpublished", exact: "0.3.0"),
+    ],
+    targets: [
+        .target(name: "Kit", dependencies: [
+            .product(name: "ArgumentParser", package: "apple.swift-argument-parser"),
+            .product(name: "LinkedList", package: "mona.LinkedList"),
+            .product(name: "Yams", package: "Yams"),
+        ]),
+    ]
+)
+`
+
+func TestRewriteRegistryDependencies(t *testing.T) {
+	cfg := RegistryDependenciesConfig{
+		Enabled: true,
+		Identifiers: map[string]string{
+			"https://github.com/apple/swift-argument-parser": "apple.swift-argument-parser",
+			"https://github.com/jpsim/Yams.git":              "jpsim.Yams",
+		},
+	}
+	var looked []string
+	lookup := func(_ context.Context, url string) ([]string, error) {
+		looked = append(looked, url)
+		if strings.HasSuffix(url, "/LinkedList") {
+			return []string{"mona.LinkedList"}, nil
+		}
+		return nil, nil
+	}
+
+	got, rewrites, err := RewriteRegistryDependencies(context.Background(), []byte(registryManifest), cfg, lookup)
+	if err != nil {
+		t.Fatalf("RewriteRegistryDependencies() error = %v", err)
+	}
+	if string(got) != registryManifestRewritten {
+		t.Errorf("rewritten manifest:\n%s", unifiedDiff("Package.swift", []byte(registryManifestRewritten), got))
+	}
+
+	want := []RegistryDependencyRewrite{
+		{Line: 7, URL: "https://github.com/apple/swift-argument-parser.git", ID: "apple.swift-argument-parser"},
+		{Line: 8, URL: "https://github.com/mona/LinkedList", ID: "mona.LinkedList"},
+	}
+	if !reflect.DeepEqual(rewrites, want) {
+		t.Errorf("rewrites = %+v, want %+v", rewrites, want)
+	}
+	// Mapped URLs and branch dependencies are not looked up
+	if wantLooked := []string{"https://github.com/mona/LinkedList", "https://github.com/org/unpublished"}; !reflect.DeepEqual(looked, wantLooked) {
+		t.Errorf("looked up %v, want %v", looked, wantLooked)
+	}
+}
+
+func TestRewriteRegistryDependencies_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		lookup   identifierLookup
+		wantErr  string
+	}{
+		{
+			name:     "old tools version",
+			manifest: strings.Replace(registryManifest, "5.9", "5.6", 1),
+			wantErr:  "require swift-tools-version 5.7",
+		},
+		{
+			name:     "ambiguous identifiers",
+			manifest: registryManifest,
+			lookup: func(context.Context, string) ([]string, error) {
+				return []string{"mona.LinkedList", "octo.LinkedList"}, nil
+			},
+			wantErr: "several identifiers",
+		},
+		{
+			name:     "lookup failure",
+			manifest: registryManifest,
+			lookup: func(context.Context, string) ([]string, error) {
+				return nil, errors.New("registry unavailable")
+			},
+			wantErr: "registry unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RegistryDependenciesConfig{
+				Enabled:     true,
+				Identifiers: map[string]string{"https://github.com/apple/swift-argument-parser": "apple.swift-argument-parser"},
+			}
+			_, _, err := RewriteRegistryDependencies(context.Background(), []byte(tt.manifest), cfg, tt.lookup)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistryClient_LookupIdentifiers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identifiers" || r.Header.Get("Accept") != "application/vnd.swift.registry.v1+json" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Accept"))
+		}
+		switch r.URL.Query().Get("url") {
+		case "https://github.com/mona/LinkedList":
+			_, _ = w.Write([]byte(`{"identifiers": ["mona.LinkedList"]}`))
+		case "https://github.com/org/broken":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, "token")
+	ids, err := client.LookupIdentifiers(context.Background(), "https://github.com/mona/LinkedList")
+	if err != nil || !reflect.DeepEqual(ids, []string{"mona.LinkedList"}) {
+		t.Errorf("LookupIdentifiers() = %v, %v", ids, err)
+	}
+	if ids, err := client.LookupIdentifiers(context.Background(), "https://github.com/org/unknown"); err != nil || ids != nil {
+		t.Errorf("LookupIdentifiers() for unknown package = %v, %v", ids, err)
+	}
+	if _, err := client.LookupIdentifiers(context.Background(), "https://github.com/org/broken"); err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestRegistryDependenciesConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RegistryDependenciesConfig
+		want string
+	}{
+		{"disabled", RegistryDependenciesConfig{}, ""},
+		{"lookup", RegistryDependenciesConfig{Enabled: true, Lookup: true}, ""},
+		{"identifiers", RegistryDependenciesConfig{Enabled: true, Identifiers: map[string]string{"https://github.com/mona/LinkedList": "mona.LinkedList"}}, ""},
+		{"nothing to map", RegistryDependenciesConfig{Enabled: true}, "requires identifiers or lookup"},
+		{"bad identifier", RegistryDependenciesConfig{Enabled: true, Identifiers: map[string]string{"https://github.com/mona/LinkedList": "LinkedList"}}, "not a scope.name identifier"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.validate()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	want := `--- a/Package.swift
++++ b/Package.swift
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got := unifiedDiff("Package.swift", []byte(old), []byte(new)); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("Package.swift", []byte(old), []byte(old)); got != "" {
+		t.Errorf("unifiedDiff() of equal files = %q", got)
+	}
+}
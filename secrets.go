@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SecretFinding describes a potential credential found in archive contents.
+type SecretFinding struct {
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+// String formats the finding as file:line: description.
+func (f SecretFinding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s (%s)", f.File, f.Line, f.Description, f.Rule)
+	}
+	return fmt.Sprintf("%s: %s (%s)", f.File, f.Description, f.Rule)
+}
+
+// secretFileRule flags files by name regardless of content.
+type secretFileRule struct {
+	id          string
+	description string
+	match       func(base string) bool
+}
+
+// secretContentRule flags lines matching a pattern.
+type secretContentRule struct {
+	id          string
+	description string
+	pattern     *regexp.Regexp
+}
+
+var secretFileRules = []secretFileRule{
+	{
+		id:          "signing-credential",
+		description: "code signing credential or provisioning profile",
+		match: func(base string) bool {
+			switch strings.ToLower(filepath.Ext(base)) {
+			case ".p12", ".pfx", ".mobileprovision", ".provisionprofile", ".p8", ".keystore", ".jks":
+				return true
+			}
+			return false
+		},
+	},
+	{
+		id:          "dotenv-file",
+		description: "environment file",
+		match: func(base string) bool {
+			if base != ".env" && !strings.HasPrefix(base, ".env.") {
+				return false
+			}
+			switch base {
+			case ".env.example", ".env.sample", ".env.template":
+				return false
+			}
+			return true
+		},
+	},
+}
+
+var secretContentRules = []secretContentRule{
+	{
+		id:          "private-key",
+		description: "private key block",
+		pattern:     regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		id:          "aws-access-key-id",
+		description: "AWS access key ID",
+		pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|A3T[A-Z0-9])[A-Z0-9]{16}\b`),
+	},
+	{
+		id:          "aws-secret-access-key",
+		description: "AWS secret access key",
+		pattern:     regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+	},
+	{
+		id:          "github-token",
+		description: "GitHub token",
+		pattern:     regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+	},
+}
+
+// configFileExtensions lists the file types checked for high-entropy strings.
+var configFileExtensions = map[string]bool{
+	".json":       true,
+	".yml":        true,
+	".yaml":       true,
+	".plist":      true,
+	".xcconfig":   true,
+	".properties": true,
+	".toml":       true,
+	".ini":        true,
+	".cfg":        true,
+	".conf":       true,
+}
+
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+// highEntropyThreshold is the Shannon entropy in bits per character above
+// which a candidate string is reported. Hex digests cannot exceed 4.0, so
+// checksums and revisions are not flagged.
+const highEntropyThreshold = 4.5
+
+// ScanArchiveSecrets scans the files CreateArchive would include for
+// credentials. Findings matching an entry in the allowlist file are dropped;
+// a missing allowlist file is not an error.
+func ScanArchiveSecrets(ctx context.Context, sourceDir string, cfg ArchiveConfig, allowlistPath string) ([]SecretFinding, error) {
+	entries, err := collectArchiveEntries(ctx, sourceDir, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect archive files: %w", err)
+	}
+
+	allowlist, err := loadSecretAllowlist(allowlistPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []SecretFinding
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fileFindings, err := scanEntryForSecrets(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", entry.name, err)
+		}
+		for _, f := range fileFindings {
+			if !allowlist.allows(f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// scanEntryForSecrets applies the file name and content rules to one entry.
+func scanEntryForSecrets(entry archiveEntry) ([]SecretFinding, error) {
+	var findings []SecretFinding
+
+	base := path.Base(entry.name)
+	for _, rule := range secretFileRules {
+		if rule.match(base) {
+			findings = append(findings, SecretFinding{File: entry.name, Rule: rule.id, Description: rule.description})
+		}
+	}
+
+	// Symlink entries carry no content of their own
+	if entry.link != "" {
+		return findings, nil
+	}
+
+	content, err := os.ReadFile(entry.path)
+	if err != nil {
+		return nil, err
+	}
+	if isBinary(content) {
+		return findings, nil
+	}
+
+	checkEntropy := configFileExtensions[strings.ToLower(path.Ext(base))]
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+
+		for _, rule := range secretContentRules {
+			if rule.pattern.MatchString(line) {
+				findings = append(findings, SecretFinding{
+					File:        entry.name,
+					Line:        lineNum,
+					Rule:        rule.id,
+					Description: rule.description,
+				})
+			}
+		}
+
+		if checkEntropy {
+			for _, candidate := range entropyCandidate.FindAllString(line, -1) {
+				if shannonEntropy(candidate) >= highEntropyThreshold {
+					findings = append(findings, SecretFinding{
+						File:        entry.name,
+						Line:        lineNum,
+						Rule:        "high-entropy-string",
+						Description: "high-entropy string in config file",
+					})
+					break
+				}
+			}
+		}
+	}
+
+	return findings, scanner.Err()
+}
+
+// isBinary reports whether content looks like binary data.
+func isBinary(content []byte) bool {
+	const sniffLen = 8000
+	if len(content) > sniffLen {
+		content = content[:sniffLen]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// secretAllowlist suppresses known false positives.
+type secretAllowlist []secretAllowEntry
+
+// secretAllowEntry matches findings by path glob and, optionally, line.
+type secretAllowEntry struct {
+	pattern string
+	line    int
+}
+
+// loadSecretAllowlist reads an allowlist file. Each non-empty line that does
+// not start with '#' is a slash-separated path glob, optionally followed by
+// ":<line>" to allow a single line only.
+func loadSecretAllowlist(allowlistPath string) (secretAllowlist, error) {
+	if allowlistPath == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(allowlistPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read secret scan allowlist: %w", err)
+	}
+
+	var allowlist secretAllowlist
+	for _, raw := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := secretAllowEntry{pattern: line}
+		if idx := strings.LastIndex(line, ":"); idx > 0 {
+			if n, err := strconv.Atoi(line[idx+1:]); err == nil {
+				entry = secretAllowEntry{pattern: line[:idx], line: n}
+			}
+		}
+		if _, err := path.Match(entry.pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid secret scan allowlist pattern %q: %w", entry.pattern, err)
+		}
+		allowlist = append(allowlist, entry)
+	}
+
+	return allowlist, nil
+}
+
+// allows reports whether a finding is suppressed by the allowlist.
+func (a secretAllowlist) allows(f SecretFinding) bool {
+	for _, entry := range a {
+		if entry.line != 0 && entry.line != f.Line {
+			continue
+		}
+		if matched, _ := path.Match(entry.pattern, f.File); matched {
+			return true
+		}
+		if !strings.Contains(entry.pattern, "/") {
+			if matched, _ := path.Match(entry.pattern, path.Base(f.File)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -3,30 +3,43 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
+// swiftOutputTail is the number of output lines kept for error messages.
+const swiftOutputTail = 20
+
 // SwiftCLI wraps Swift command-line operations.
 type SwiftCLI struct {
 	workDir string
+	logger  *slog.Logger
+	// logDir receives the full output of each step when set.
+	logDir string
 }
 
-// NewSwiftCLI creates a new SwiftCLI instance.
-func NewSwiftCLI(workDir string) *SwiftCLI {
-	return &SwiftCLI{workDir: workDir}
+// NewSwiftCLI creates a new SwiftCLI instance that streams command output
+// to logger and, when logDir is set, to <logDir>/<step>.log.
+func NewSwiftCLI(workDir string, logger *slog.Logger, logDir string) *SwiftCLI {
+	return &SwiftCLI{workDir: workDir, logger: logger, logDir: logDir}
 }
 
 // Validate validates the package manifest.
 func (s *SwiftCLI) Validate(ctx context.Context) error {
 	// Check manifest syntax by dumping package
-	if err := s.run(ctx, "package", "dump-package"); err != nil {
+	if err := s.run(ctx, "dump-package", "package", "dump-package"); err != nil {
 		return fmt.Errorf("invalid Package.swift: %w", err)
 	}
 
 	// Resolve dependencies to validate they're accessible
-	if err := s.run(ctx, "package", "resolve"); err != nil {
+	if err := s.run(ctx, "resolve", "package", "resolve"); err != nil {
 		return fmt.Errorf("failed to resolve dependencies: %w", err)
 	}
 
@@ -39,12 +52,21 @@ func (s *SwiftCLI) Build(ctx context.Context, configuration string) error {
 	if configuration != "" {
 		args = append(args, "-c", configuration)
 	}
-	return s.run(ctx, args...)
+	return s.run(ctx, "build", args...)
 }
 
-// Test runs package tests.
-func (s *SwiftCLI) Test(ctx context.Context, cfg TestConfig) error {
-	args := []string{"test"}
+// Test runs package tests and returns the results read from their xUnit
+// reports, or nil when the toolchain wrote none. A report that cannot be read
+// fails Test only when the tests failed too; otherwise it is logged.
+func (s *SwiftCLI) Test(ctx context.Context, cfg TestConfig) (*TestSummary, error) {
+	resultsDir, err := os.MkdirTemp("", "swift-test-results-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test results directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(resultsDir) }()
+	xunitPath := filepath.Join(resultsDir, "xunit.xml")
+
+	args := []string{"test", "--xunit-output", xunitPath}
 	if cfg.Configuration != "" {
 		args = append(args, "-c", cfg.Configuration)
 	}
@@ -54,12 +76,35 @@ func (s *SwiftCLI) Test(ctx context.Context, cfg TestConfig) error {
 	if cfg.Parallel {
 		args = append(args, "--parallel")
 	}
-	return s.run(ctx, args...)
+	runErr := s.run(ctx, "test", args...)
+
+	summary, err := LoadTestSummary(xunitReportPaths(xunitPath)...)
+	if err != nil && runErr == nil {
+		// The tests passed; an unreadable report only loses the summary
+		s.log().Warn("Failed to read test results", "error", err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Join(runErr, err)
+	}
+	return summary, runErr
+}
+
+// DiagnoseAPIBreakingChanges compares the public API of the package's
+// library products with the baseline git treeish and returns the report.
+// The command fails when it finds breakages, so that is not an error.
+func (s *SwiftCLI) DiagnoseAPIBreakingChanges(ctx context.Context, baseline string) (string, error) {
+	var output strings.Builder
+	err := s.execute(ctx, "diagnose-api-breaking-changes", &output, "package", "diagnose-api-breaking-changes", baseline)
+	if err != nil && len(ParseAPIBreakages(output.String())) == 0 {
+		return "", err
+	}
+	return output.String(), nil
 }
 
 // Clean cleans build artifacts.
 func (s *SwiftCLI) Clean(ctx context.Context) error {
-	return s.run(ctx, "package", "clean")
+	return s.run(ctx, "clean", "package", "clean")
 }
 
 // GetVersion returns the Swift version.
@@ -75,16 +120,73 @@ func (s *SwiftCLI) GetVersion(ctx context.Context) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// run executes a swift command.
-func (s *SwiftCLI) run(ctx context.Context, args ...string) error {
+// log returns the logger, discarding records when none was given.
+func (s *SwiftCLI) log() *slog.Logger {
+	if s.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return s.logger
+}
+
+// run executes a swift command for a step.
+func (s *SwiftCLI) run(ctx context.Context, step string, args ...string) error {
+	return s.execute(ctx, step, nil, args...)
+}
+
+// execute runs a swift command, logging its stdout and stderr line by line
+// at debug level as they arrive, with the step name. Output is also appended
+// to output when it is not nil. A failure carries the last lines of stderr,
+// or of stdout when stderr is empty.
+func (s *SwiftCLI) execute(ctx context.Context, step string, output io.Writer, args ...string) error {
 	cmd := exec.CommandContext(ctx, "swift", args...)
 	cmd.Dir = s.workDir
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	logger := s.log().With("step", step)
+
+	var logFile *os.File
+	if s.logDir != "" {
+		if err := os.MkdirAll(s.logDir, 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+		var err error
+		logFile, err = os.Create(filepath.Join(s.logDir, step+".log"))
+		if err != nil {
+			return fmt.Errorf("failed to create log file: %w", err)
+		}
+		defer func() { _ = logFile.Close() }()
+	}
+
+	// stdout and stderr are copied concurrently, so each keeps its own tail
+	var mu sync.Mutex
+	tails := map[string][]string{}
+	emit := func(stream, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		logger.Debug("swift output", "stream", stream, "line", line)
+		tail := tails[stream]
+		if len(tail) == swiftOutputTail {
+			tail = tail[1:]
+		}
+		tails[stream] = append(tail, line)
+		if logFile != nil {
+			_, _ = fmt.Fprintln(logFile, line)
+		}
+		if output != nil {
+			_, _ = fmt.Fprintln(output, line)
+		}
+	}
+	stdout := &lineWriter{emit: func(line string) { emit("stdout", line) }}
+	stderr := &lineWriter{emit: func(line string) { emit("stderr", line) }}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
 
-	if err := cmd.Run(); err != nil {
-		errOutput := strings.TrimSpace(stderr.String())
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	if err != nil {
+		errOutput := strings.TrimSpace(strings.Join(tails["stderr"], "\n"))
+		if errOutput == "" {
+			errOutput = strings.TrimSpace(strings.Join(tails["stdout"], "\n"))
+		}
 		if errOutput != "" {
 			return fmt.Errorf("%s: %w", errOutput, err)
 		}
@@ -93,3 +195,30 @@ func (s *SwiftCLI) run(ctx context.Context, args ...string) error {
 
 	return nil
 }
+
+// lineWriter calls emit with each complete line written to it.
+type lineWriter struct {
+	buf  bytes.Buffer
+	emit func(line string)
+}
+
+// Write buffers p and emits the complete lines it holds.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := strings.TrimRight(string(w.buf.Next(i+1)), "\r\n")
+		w.emit(line)
+	}
+}
+
+// Flush emits a final line without a newline.
+func (w *lineWriter) Flush() {
+	if w.buf.Len() > 0 {
+		w.emit(strings.TrimRight(w.buf.String(), "\r"))
+		w.buf.Reset()
+	}
+}
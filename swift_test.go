@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSwift puts a swift executable running script first on PATH.
+func fakeSwift(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake swift needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "swift"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSwiftCLI_RunStreamsOutput(t *testing.T) {
+	fakeSwift(t, `echo "Building for production..."
+printf 'Compiling Kit\r\n'
+for i in $(seq 1 25); do echo "error $i" >&2; done
+for i in $(seq 1 30); do echo "line $i"; done
+printf 'no newline'
+exit 1
+`)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logDir := filepath.Join(t.TempDir(), "logs")
+	swift := NewSwiftCLI(t.TempDir(), logger, logDir)
+
+	err := swift.Build(context.Background(), "release")
+	if err == nil {
+		t.Fatal("expected build to fail")
+	}
+
+	// The error carries only the last lines of stderr
+	want := make([]string, 0, swiftOutputTail)
+	for i := 25 - swiftOutputTail + 1; i <= 25; i++ {
+		want = append(want, fmt.Sprintf("error %d", i))
+	}
+	if msg := err.Error(); msg != strings.Join(want, "\n")+": exit status 1" {
+		t.Errorf("error = %q", msg)
+	}
+
+	for _, want := range []string{
+		`level=DEBUG msg="swift output" step=build stream=stdout line="Building for production..."`,
+		`level=DEBUG msg="swift output" step=build stream=stdout line="Compiling Kit"`,
+		`level=DEBUG msg="swift output" step=build stream=stderr line="error 1"`,
+		`level=DEBUG msg="swift output" step=build stream=stdout line="no newline"`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs missing %q:\n%s", want, logs.String())
+		}
+	}
+
+	log, err := os.ReadFile(filepath.Join(logDir, "build.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(log), "\n"); lines != 58 {
+		t.Errorf("build.log has %d lines, want 58", lines)
+	}
+}
+
+func TestSwiftCLI_RunStdoutTail(t *testing.T) {
+	fakeSwift(t, `echo "Building for production..."
+for i in $(seq 1 30); do echo "line $i"; done
+printf 'no newline'
+exit 1
+`)
+
+	err := NewSwiftCLI(t.TempDir(), nil, "").Build(context.Background(), "")
+	if err == nil {
+		t.Fatal("expected build to fail")
+	}
+
+	// Without stderr, the error carries the last lines of stdout
+	want := make([]string, 0, swiftOutputTail)
+	for i := 30 - swiftOutputTail + 2; i <= 30; i++ {
+		want = append(want, fmt.Sprintf("line %d", i))
+	}
+	want = append(want, "no newline")
+	if msg := err.Error(); msg != strings.Join(want, "\n")+": exit status 1" {
+		t.Errorf("error = %q", msg)
+	}
+}
+
+func TestSwiftCLI_DiagnoseAPIBreakingChanges(t *testing.T) {
+	fakeSwift(t, fmt.Sprintf("cat <<'EOF'\n%sEOF\nexit 1\n", apiBreakageReport))
+
+	report, err := NewSwiftCLI(t.TempDir(), nil, "").DiagnoseAPIBreakingChanges(context.Background(), "v1.2.0")
+	if err != nil {
+		t.Fatalf("DiagnoseAPIBreakingChanges() error = %v", err)
+	}
+	if got := len(ParseAPIBreakages(report)); got != 3 {
+		t.Errorf("report has %d breakages, want 3:\n%s", got, report)
+	}
+
+	fakeSwift(t, "echo 'error: the baseline v9.9.9 does not exist' >&2\nexit 1\n")
+	if _, err := NewSwiftCLI(t.TempDir(), nil, "").DiagnoseAPIBreakingChanges(context.Background(), "v9.9.9"); err == nil ||
+		!strings.Contains(err.Error(), "baseline v9.9.9 does not exist") {
+		t.Errorf("expected command failure, got %v", err)
+	}
+}
+
+// fakeSwiftTest is a swift test that writes the XCTest and swift-testing
+// reports and fails.
+const fakeSwiftTest = `while [ $# -gt 0 ]; do
+  if [ "$1" = --xunit-output ]; then out=$2; fi
+  shift
+done
+cat > "$out" <<'EOF'
+` + xctestReport + `EOF
+cat > "${out%.xml}-swift-testing.xml" <<'EOF'
+` + swiftTestingReport + `EOF
+echo "error: fatalError" >&2
+exit 1
+`
+
+func TestSwiftCLI_Test(t *testing.T) {
+	fakeSwift(t, fakeSwiftTest)
+
+	summary, err := NewSwiftCLI(t.TempDir(), nil, "").Test(context.Background(), TestConfig{Configuration: "debug", Parallel: true})
+	if err == nil || !strings.Contains(err.Error(), "error: fatalError") {
+		t.Errorf("expected test failure, got %v", err)
+	}
+	if summary == nil || summary.Tests != 5 || summary.Failed != 2 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	// Toolchains that write no report only report the failure
+	fakeSwift(t, "exit 1\n")
+	summary, err = NewSwiftCLI(t.TempDir(), nil, "").Test(context.Background(), TestConfig{})
+	if err == nil || summary != nil {
+		t.Errorf("Test() without reports = %+v, %v", summary, err)
+	}
+}
+
+func TestSwiftCLI_Test_CorruptReport(t *testing.T) {
+	const corrupt = `while [ $# -gt 0 ]; do
+  if [ "$1" = --xunit-output ]; then out=$2; fi
+  shift
+done
+printf '<testsuites><testsuite name="KitTests"><testcase' > "$out"
+exit %d
+`
+
+	// Passing tests are not failed by an unreadable report
+	fakeSwift(t, fmt.Sprintf(corrupt, 0))
+	var logs bytes.Buffer
+	swift := NewSwiftCLI(t.TempDir(), slog.New(slog.NewTextHandler(&logs, nil)), "")
+	summary, err := swift.Test(context.Background(), TestConfig{})
+	if err != nil || summary != nil {
+		t.Errorf("Test() with passing tests = %+v, %v", summary, err)
+	}
+	if !strings.Contains(logs.String(), `msg="Failed to read test results"`) {
+		t.Errorf("expected a warning about the report, got:\n%s", logs.String())
+	}
+
+	// Failing tests report both errors
+	fakeSwift(t, fmt.Sprintf(corrupt, 1))
+	_, err = NewSwiftCLI(t.TempDir(), nil, "").Test(context.Background(), TestConfig{})
+	if err == nil || !strings.Contains(err.Error(), "exit status 1") || !strings.Contains(err.Error(), "failed to parse xunit.xml") {
+		t.Errorf("Test() with failing tests = %v", err)
+	}
+}
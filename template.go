@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateData holds the fields available to configured templates.
+type templateData struct {
+	Version string
+	Tag     string
+	// Major, Minor, Patch, Prerelease and Build are the semantic version
+	// components of Version; the numbers are zero if it is not a semantic
+	// version.
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// newTemplateData returns the template fields for a release version.
+func newTemplateData(version, tagPrefix string) templateData {
+	data := templateData{Version: version, Tag: tagPrefix + version}
+	core, build, _ := strings.Cut(version, "+")
+	core, data.Prerelease, _ = strings.Cut(core, "-")
+	data.Build = build
+	data.Major, data.Minor, data.Patch, _ = versionComponents(core)
+	return data
+}
+
+// templateFuncs are the functions available to configured templates.
+var templateFuncs = template.FuncMap{
+	// swiftString quotes a value as a Swift string literal
+	"swiftString": swiftStringLiteral,
+}
+
+// newTemplate parses a configured text/template string.
+func newTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes a text/template string with data.
+func renderTemplate(name, text string, data any) (string, error) {
+	tmpl, err := newTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewTemplateData(t *testing.T) {
+	tests := []struct {
+		version string
+		want    templateData
+	}{
+		{"1.2.3", templateData{Version: "1.2.3", Tag: "v1.2.3", Major: 1, Minor: 2, Patch: 3}},
+		{"2.0.0-beta.1", templateData{Version: "2.0.0-beta.1", Tag: "v2.0.0-beta.1", Major: 2, Prerelease: "beta.1"}},
+		{"2.0.0-rc.1+build.5", templateData{Version: "2.0.0-rc.1+build.5", Tag: "v2.0.0-rc.1+build.5", Major: 2, Prerelease: "rc.1", Build: "build.5"}},
+		{"nightly", templateData{Version: "nightly", Tag: "vnightly"}},
+	}
+
+	for _, tt := range tests {
+		if got := newTemplateData(tt.version, "v"); got != tt.want {
+			t.Errorf("newTemplateData(%q) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	data := newTemplateData("1.2.0-beta.1", "v")
+	tests := []struct {
+		text    string
+		want    string
+		wantErr string
+	}{
+		{text: "{{.Tag}} {{.Major}}.{{.Minor}}", want: "v1.2.0-beta.1 1.2"},
+		{text: `let v = {{swiftString .Prerelease}}`, want: `let v = "beta.1"`},
+		{text: "{{.Missing}}", wantErr: "failed to render test template"},
+		{text: "{{.Tag", wantErr: "invalid test template"},
+	}
+
+	for _, tt := range tests {
+		got, err := renderTemplate("test", tt.text, data)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("renderTemplate(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("renderTemplate(%q) error = %v", tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("renderTemplate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Test case statuses.
+const (
+	TestPassed  = "passed"
+	TestFailed  = "failed"
+	TestSkipped = "skipped"
+)
+
+// TestSummary is the outcome of swift test, read from its xUnit reports.
+type TestSummary struct {
+	Tests   int `json:"tests"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	// Duration is the total test time in seconds.
+	Duration float64 `json:"duration"`
+	// Failures are the failed test cases, in report order.
+	Failures []TestCaseResult `json:"failures,omitempty"`
+	Cases    []TestCaseResult `json:"-"`
+}
+
+// TestCaseResult is the result of one test case.
+type TestCaseResult struct {
+	// Suite is the xUnit classname, such as KitTests.ClientTests.
+	Suite string `json:"suite"`
+	Name  string `json:"name"`
+	// Status is one of the Test* constants.
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	File     string  `json:"file,omitempty"`
+	Line     int     `json:"line,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// xunitDocument is a <testsuites> report, or a single <testsuite>.
+type xunitDocument struct {
+	XMLName xml.Name
+	Suites  []xunitSuite `xml:"testsuite"`
+	Cases   []xunitCase  `xml:"testcase"`
+}
+
+type xunitSuite struct {
+	Cases []xunitCase `xml:"testcase"`
+}
+
+type xunitCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *xunitFailure `xml:"failure"`
+	Error     *xunitFailure `xml:"error"`
+	Skipped   *struct{}     `xml:"skipped"`
+}
+
+type xunitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// failureLocation matches the file:line of a failure, such as
+// /src/Tests/KitTests/ClientTests.swift:42.
+var failureLocation = regexp.MustCompile(`([^\s:"()]+\.swift):(\d+)`)
+
+// xunitReportPaths returns the reports swift test writes for
+// --xunit-output path: XCTest results at path and swift-testing results
+// next to it, with a -swift-testing suffix.
+func xunitReportPaths(path string) []string {
+	ext := filepath.Ext(path)
+	return []string{path, strings.TrimSuffix(path, ext) + "-swift-testing" + ext}
+}
+
+// LoadTestSummary reads and merges the xUnit reports at paths, skipping
+// missing ones. It returns nil when none exists.
+func LoadTestSummary(paths ...string) (*TestSummary, error) {
+	var summary *TestSummary
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read test results: %w", err)
+		}
+		cases, err := ParseXUnit(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+		}
+		if summary == nil {
+			summary = &TestSummary{}
+		}
+		for _, c := range cases {
+			summary.add(c)
+		}
+	}
+	return summary, nil
+}
+
+// add counts a test case in the summary.
+func (s *TestSummary) add(c TestCaseResult) {
+	s.Tests++
+	s.Duration += c.Duration
+	switch c.Status {
+	case TestFailed:
+		s.Failed++
+		s.Failures = append(s.Failures, c)
+	case TestSkipped:
+		s.Skipped++
+	default:
+		s.Passed++
+	}
+	s.Cases = append(s.Cases, c)
+}
+
+// ParseXUnit reads the test cases of an xUnit report written by XCTest or
+// swift-testing.
+func ParseXUnit(data []byte) ([]TestCaseResult, error) {
+	var doc xunitDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	cases := doc.Cases
+	for _, suite := range doc.Suites {
+		cases = append(cases, suite.Cases...)
+	}
+
+	results := make([]TestCaseResult, 0, len(cases))
+	for _, c := range cases {
+		result := TestCaseResult{Suite: c.Classname, Name: c.Name, Status: TestPassed}
+		result.Duration, _ = strconv.ParseFloat(c.Time, 64)
+		failure := c.Failure
+		if failure == nil {
+			failure = c.Error
+		}
+		switch {
+		case failure != nil:
+			result.Status = TestFailed
+			result.Message = strings.TrimSpace(failure.Message)
+			if result.Message == "" {
+				result.Message, _, _ = strings.Cut(strings.TrimSpace(failure.Text), "\n")
+			}
+			if m := failureLocation.FindStringSubmatch(failure.Message + "\n" + failure.Text); m != nil {
+				result.File = m[1]
+				result.Line, _ = strconv.Atoi(m[2])
+			}
+		case c.Skipped != nil:
+			result.Status = TestSkipped
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// FailureSummary describes the outcome and up to limit failed tests.
+func (s *TestSummary) FailureSummary(limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d tests failed", s.Failed, s.Tests)
+	for i, f := range s.Failures {
+		if i == limit {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(s.Failures)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "\n  %s.%s", f.Suite, f.Name)
+		if f.File != "" {
+			fmt.Fprintf(&b, " (%s:%d)", filepath.Base(f.File), f.Line)
+		}
+		if f.Message != "" {
+			fmt.Fprintf(&b, ": %s", f.Message)
+		}
+	}
+	return b.String()
+}
+
+// junitSuites is the root of a JUnit report.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+	Skipped   *struct{}     `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the summary as a JUnit XML report with a test
+// suite per classname.
+func WriteJUnitReport(path string, s *TestSummary) error {
+	seconds := func(d float64) string { return strconv.FormatFloat(d, 'f', 3, 64) }
+
+	report := junitSuites{Tests: s.Tests, Failures: s.Failed, Skipped: s.Skipped, Time: seconds(s.Duration)}
+	suites := make(map[string]int)
+	var durations []float64
+	for _, c := range s.Cases {
+		i, ok := suites[c.Suite]
+		if !ok {
+			i = len(report.Suites)
+			suites[c.Suite] = i
+			report.Suites = append(report.Suites, junitSuite{Name: c.Suite})
+			durations = append(durations, 0)
+		}
+		suite := &report.Suites[i]
+		suite.Tests++
+		durations[i] += c.Duration
+
+		tc := junitCase{Classname: c.Suite, Name: c.Name, Time: seconds(c.Duration), File: c.File, Line: c.Line}
+		switch c.Status {
+		case TestFailed:
+			suite.Failures++
+			location := ""
+			if c.File != "" {
+				location = fmt.Sprintf("%s:%d", c.File, c.Line)
+			}
+			tc.Failure = &junitFailure{Message: c.Message, Text: location}
+		case TestSkipped:
+			suite.Skipped++
+			tc.Skipped = &struct{}{}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = seconds(durations[i])
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
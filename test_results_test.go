@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const xctestReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+<testsuite name="TestResults" errors="0" tests="3" failures="1" time="0.62">
+<testcase classname="KitTests.ClientTests" name="testSend" time="0.120">
+</testcase>
+<testcase classname="KitTests.ClientTests" name="testTimeout" time="0.300">
+<failure message="XCTAssertEqual failed: (&quot;408&quot;) is not equal to (&quot;200&quot;)">/src/Tests/KitTests/ClientTests.swift:42: error: -[KitTests.ClientTests testTimeout]</failure>
+</testcase>
+<testcase classname="KitTests.CacheTests" name="testEviction" time="0.200">
+<failure message="failed"></failure>
+</testcase>
+</testsuite>
+</testsuites>
+`
+
+const swiftTestingReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="TestResults" errors="0" tests="2" failures="0" skipped="1" time="0.004">
+    <testcase classname="KitTests.ParserTests" name="parsesVersion()" time="0.004"/>
+    <testcase classname="KitTests.ParserTests" name="parsesBuildMetadata()" time="0">
+      <skipped/>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+func TestParseXUnit(t *testing.T) {
+	got, err := ParseXUnit([]byte(xctestReport))
+	if err != nil {
+		t.Fatalf("ParseXUnit() error = %v", err)
+	}
+	want := []TestCaseResult{
+		{Suite: "KitTests.ClientTests", Name: "testSend", Status: TestPassed, Duration: 0.12},
+		{Suite: "KitTests.ClientTests", Name: "testTimeout", Status: TestFailed, Duration: 0.3,
+			File: "/src/Tests/KitTests/ClientTests.swift", Line: 42, Message: `XCTAssertEqual failed: ("408") is not equal to ("200")`},
+		{Suite: "KitTests.CacheTests", Name: "testEviction", Status: TestFailed, Duration: 0.2, Message: "failed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseXUnit() = %+v, want %+v", got, want)
+	}
+
+	// A bare <testsuite> root
+	got, err = ParseXUnit([]byte(`<testsuite name="Kit"><testcase classname="KitTests.A" name="testA" time="1.5"><error>crashed</error></testcase></testsuite>`))
+	if err != nil {
+		t.Fatalf("ParseXUnit() error = %v", err)
+	}
+	if want := []TestCaseResult{{Suite: "KitTests.A", Name: "testA", Status: TestFailed, Duration: 1.5, Message: "crashed"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseXUnit() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseXUnit([]byte("<testsuites>")); err == nil {
+		t.Error("expected error for truncated report")
+	}
+}
+
+func TestLoadTestSummary(t *testing.T) {
+	dir := t.TempDir()
+	paths := xunitReportPaths(filepath.Join(dir, "xunit.xml"))
+	if summary, err := LoadTestSummary(paths...); err != nil || summary != nil {
+		t.Fatalf("LoadTestSummary() without reports = %+v, %v", summary, err)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"xunit.xml":               xctestReport,
+		"xunit-swift-testing.xml": swiftTestingReport,
+	})
+	summary, err := LoadTestSummary(paths...)
+	if err != nil {
+		t.Fatalf("LoadTestSummary() error = %v", err)
+	}
+	if summary.Tests != 5 || summary.Passed != 2 || summary.Failed != 2 || summary.Skipped != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if summary.Duration < 0.623 || summary.Duration > 0.625 {
+		t.Errorf("duration = %v, want 0.624", summary.Duration)
+	}
+
+	want := `2 of 5 tests failed
+  KitTests.ClientTests.testTimeout (ClientTests.swift:42): XCTAssertEqual failed: ("408") is not equal to ("200")
+  KitTests.CacheTests.testEviction: failed`
+	if got := summary.FailureSummary(5); got != want {
+		t.Errorf("FailureSummary() =\n%s\nwant\n%s", got, want)
+	}
+	if got := summary.FailureSummary(1); !strings.HasSuffix(got, "\n  ... and 1 more") {
+		t.Errorf("FailureSummary(1) = %q", got)
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"xunit.xml":               xctestReport,
+		"xunit-swift-testing.xml": swiftTestingReport,
+	})
+	summary, err := LoadTestSummary(xunitReportPaths(filepath.Join(dir, "xunit.xml"))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "reports", "junit.xml")
+	if err := WriteJUnitReport(path, summary); err != nil {
+		t.Fatalf("WriteJUnitReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<testsuites tests="5" failures="2" skipped="1" time="0.624">`,
+		`<testsuite name="KitTests.ClientTests" tests="2" failures="1" skipped="0" time="0.420">`,
+		`<testcase classname="KitTests.ClientTests" name="testTimeout" time="0.300" file="/src/Tests/KitTests/ClientTests.swift" line="42">`,
+		`<failure message="XCTAssertEqual failed: (&#34;408&#34;) is not equal to (&#34;200&#34;)">/src/Tests/KitTests/ClientTests.swift:42</failure>`,
+		`<testsuite name="KitTests.ParserTests" tests="2" failures="0" skipped="1" time="0.004">`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report missing %s:\n%s", want, data)
+		}
+	}
+
+	// The report reads back as the same results
+	cases, err := ParseXUnit(data)
+	if err != nil {
+		t.Fatalf("ParseXUnit() error = %v", err)
+	}
+	if !reflect.DeepEqual(cases, summary.Cases) {
+		t.Errorf("round trip = %+v, want %+v", cases, summary.Cases)
+	}
+}
@@ -0,0 +1,32 @@
+// swift-tools-version:5.9
+import PackageDescription
+import Foundation
+
+let name = "Dynamic"
+let useLocal = ProcessInfo.processInfo.environment["USE_LOCAL_DEPS"] != nil
+
+let dependencies: [Package.Dependency] = useLocal
+    ? [.package(path: "../Core")]
+    : [.package(url: "https://github.com/example/core.git", from: "1.0.0")]
+
+let package = Package(
+    name: name,
+    products: [
+        .library(name: name, targets: [name]),
+        .library(name: "\(name)Extras", targets: ["\(name)Extras"]),
+    ],
+    dependencies: dependencies,
+    targets: [
+        .target(name: name, path: "Sources/" + name),
+        .target(name: "\(name)Extras", dependencies: [.target(name: name)]),
+        makeTestTarget(),
+    ]
+)
+
+#if os(Linux)
+package.targets.append(.target(name: "LinuxSupport"))
+#endif
+
+func makeTestTarget() -> Target {
+    .testTarget(name: "DynamicTests", dependencies: ["Dynamic"])
+}
@@ -0,0 +1,55 @@
+// swift-tools-version:5.7
+import PackageDescription
+
+let package = Package(
+    name: "Networking",
+    platforms: [
+        .macOS(.v12),
+        .iOS(.v15),
+        .watchOS("8.0"),
+    ],
+    products: [
+        .library(name: "Networking", targets: ["Networking"]),
+        .library(name: "NetworkingDynamic", type: .dynamic, targets: ["Networking"]),
+        .executable(name: "net-cli", targets: ["NetCLI"]),
+    ],
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-log.git", from: "1.5.0"),
+        .package(url: "https://github.com/apple/swift-collections", .upToNextMinor(from: "1.0.4")),
+        .package(url: "https://github.com/apple/swift-argument-parser.git", "1.2.0"..<"1.4.0"),
+        .package(url: "https://github.com/apple/swift-nio.git", exact: "2.58.0"),
+        .package(url: "https://github.com/pointfreeco/swift-snapshot-testing", branch: "main"),
+        /* .package(url: "https://github.com/example/disabled", from: "1.0.0"), */
+    ],
+    targets: [
+        .target(
+            name: "Networking",
+            dependencies: [
+                .product(name: "Logging", package: "swift-log"),
+                .product(name: "Collections", package: "swift-collections", condition: .when(platforms: [.macOS, .linux])),
+            ],
+            exclude: ["README.md"],
+            resources: [
+                .process("Resources/Certificates"),
+                .copy("Resources/Fixtures"),
+            ],
+            swiftSettings: [
+                .define("NETWORKING_TRACE", .when(configuration: .debug)),
+                .unsafeFlags(["-Xfrontend", "-warn-long-function-bodies=200"]),
+            ]
+        ),
+        .executableTarget(
+            name: "NetCLI",
+            dependencies: [
+                "Networking",
+                .product(name: "ArgumentParser", package: "swift-argument-parser"),
+            ]
+        ),
+        .testTarget(
+            name: "NetworkingTests",
+            dependencies: ["Networking", .target(name: "NetCLI")],
+            path: "Tests/Unit"
+        ),
+    ],
+    swiftLanguageVersions: [.v5]
+)
@@ -0,0 +1,49 @@
+// swift-tools-version: 6.0
+import PackageDescription
+import CompilerPluginSupport
+
+let version = "2.1.0"
+let checksum = "6d0f8b5a1c7e4e3f2a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e7f"
+
+let package = Package(
+    name: "Toolkit",
+    platforms: [.macOS(.v10_15), .iOS("17.0"), .visionOS(.v1)],
+    products: [
+        .library(name: "Toolkit", type: .static, targets: ["Toolkit", "ToolkitCore"]),
+        .plugin(name: "Formatter", targets: ["Formatter"]),
+    ],
+    dependencies: [
+        .package(id: "mona.LinkedList", from: "1.0.0"),
+        .package(id: "mona.Queue", exact: "0.3.1"),
+        .package(path: "../Shared"),
+        .package(url: "https://github.com/swiftlang/swift-syntax.git", "600.0.0"..."600.0.1"),
+        .package(url: "git@github.com:example/private-kit.git", revision: "a4f22c34bb5b4e8a6a5b2c15d8c3b8c2e2a1f0e9"),
+    ],
+    targets: [
+        .target(name: "Toolkit", dependencies: ["ToolkitCore", "ToolkitMacros"]),
+        .binaryTarget(
+            name: "ToolkitCore",
+            url: "https://example.com/releases/ToolkitCore.xcframework.zip",
+            checksum: checksum
+        ),
+        .macro(
+            name: "ToolkitMacros",
+            dependencies: [
+                .product(name: "SwiftSyntaxMacros", package: "swift-syntax"),
+            ]
+        ),
+        .plugin(name: "Formatter", capability: .buildTool()),
+        .systemLibrary(name: "CZlib", path: "Sources/CZlib", pkgConfig: "zlib"),
+        .target(
+            name: "ToolkitC",
+            path: "Sources/ToolkitC",
+            sources: ["src"],
+            publicHeadersPath: "include",
+            cSettings: [.headerSearchPath("private")],
+            linkerSettings: [.linkedLibrary("z", .when(platforms: [.linux]))]
+        ),
+    ],
+    swiftLanguageModes: [.v5, .version("6")],
+    cLanguageStandard: .c11,
+    cxxLanguageStandard: .gnucxx17
+)
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionFileConfig stamps the release version into source files matching a
+// glob. An entry either names constants to update or supplies a regular
+// expression whose matches are replaced with a rendered template.
+type VersionFileConfig struct {
+	// Path is a slash-separated glob relative to the package root. A **
+	// segment matches any number of directories.
+	Path string `json:"path"`
+	// Constant names a String constant set to the full version.
+	Constant string `json:"constant"`
+	// Major, Minor and Patch name Int constants set to the version
+	// components.
+	Major string `json:"major"`
+	Minor string `json:"minor"`
+	Patch string `json:"patch"`
+	// Prerelease names a String or String? constant set to the prerelease
+	// identifier; an optional is set to nil for a final release.
+	Prerelease string `json:"prerelease"`
+	// Pattern is a regular expression replaced by Template.
+	Pattern string `json:"pattern"`
+	// Template is a text/template producing the replacement. After
+	// rendering, $1 or ${name} expand to the pattern's capture groups.
+	Template string `json:"template"`
+}
+
+// constants returns the named constants and the kind of value each holds.
+func (c VersionFileConfig) constants() []versionConstant {
+	var constants []versionConstant
+	for _, constant := range []versionConstant{
+		{c.Constant, constantVersion},
+		{c.Major, constantMajor},
+		{c.Minor, constantMinor},
+		{c.Patch, constantPatch},
+		{c.Prerelease, constantPrerelease},
+	} {
+		if constant.name != "" {
+			constants = append(constants, constant)
+		}
+	}
+	return constants
+}
+
+// validate reports a configuration problem, or "".
+func (c VersionFileConfig) validate() string {
+	hasConstants := len(c.constants()) > 0
+	switch {
+	case c.Path == "":
+		return "requires path"
+	case hasConstants && c.Pattern != "":
+		return "sets both constants and pattern"
+	case !hasConstants && c.Pattern == "":
+		return "requires constant, major, minor, patch, prerelease or pattern"
+	case c.Pattern != "" && c.Template == "":
+		return "pattern requires template"
+	}
+	if _, err := path.Match(strings.ReplaceAll(c.Path, "**", "*"), ""); err != nil {
+		return fmt.Sprintf("invalid path glob %q", c.Path)
+	}
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Sprintf("invalid pattern: %v", err)
+		}
+		if _, err := newTemplate("version_files.template", c.Template); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// Kinds of value a named version constant holds.
+type versionConstantKind int
+
+const (
+	constantVersion versionConstantKind = iota
+	constantMajor
+	constantMinor
+	constantPatch
+	constantPrerelease
+)
+
+type versionConstant struct {
+	name string
+	kind versionConstantKind
+}
+
+// pattern matches a let or var declaration of the constant, with any access,
+// static or class modifiers. Group 1 is the text before the value, group 2
+// is set for an optional String, and group 3 is the value.
+func (c versionConstant) pattern() *regexp.Regexp {
+	value, typ := `"(?:[^"\\\n]|\\.)*"|nil`, `String(\?)?`
+	if c.kind == constantMajor || c.kind == constantMinor || c.kind == constantPatch {
+		value, typ = `\d+`, `Int()`
+	}
+	return regexp.MustCompile(fmt.Sprintf(`(\b(?:let|var)\s+%s\s*(?::\s*%s\s*)?=\s*)(%s)`,
+		regexp.QuoteMeta(c.name), typ, value))
+}
+
+// value returns the Swift literal for the constant.
+func (c versionConstant) value(data templateData, optional bool) string {
+	switch c.kind {
+	case constantMajor:
+		return strconv.Itoa(data.Major)
+	case constantMinor:
+		return strconv.Itoa(data.Minor)
+	case constantPatch:
+		return strconv.Itoa(data.Patch)
+	case constantPrerelease:
+		if data.Prerelease == "" && optional {
+			return "nil"
+		}
+		return swiftStringLiteral(data.Prerelease)
+	default:
+		return swiftStringLiteral(data.Version)
+	}
+}
+
+// swiftStringLiteral quotes s as a Swift string literal.
+func swiftStringLiteral(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// VersionReplacement records a value replaced in a version file.
+type VersionReplacement struct {
+	// File is the slash-separated path relative to the package root.
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Name is the constant name, or the pattern for pattern entries.
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// StampVersionFiles applies the version file entries under workDir and
+// returns every replacement. Files are only written when write is set, and
+// only once every entry has been applied successfully.
+func StampVersionFiles(workDir string, entries []VersionFileConfig, data templateData, write bool) ([]VersionReplacement, error) {
+	var replacements []VersionReplacement
+	edits := newFileEdits(workDir)
+
+	for i, entry := range entries {
+		files, err := globFiles(workDir, entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("version_files[%d]: %w", i, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("version_files[%d]: no files match %q", i, entry.Path)
+		}
+
+		found := make(map[string]bool)
+		for _, file := range files {
+			content, err := edits.read(file)
+			if err != nil {
+				return nil, err
+			}
+
+			var fileReplacements []VersionReplacement
+			if entry.Pattern != "" {
+				content, fileReplacements, err = replacePattern(content, entry, data)
+				if err != nil {
+					return nil, fmt.Errorf("version_files[%d]: %w", i, err)
+				}
+			} else {
+				content, fileReplacements = replaceConstants(content, entry.constants(), data)
+			}
+
+			for j := range fileReplacements {
+				fileReplacements[j].File = file
+				found[fileReplacements[j].Name] = true
+			}
+			replacements = append(replacements, fileReplacements...)
+			edits.set(file, content)
+		}
+
+		if entry.Pattern != "" {
+			if !found[entry.Pattern] {
+				return nil, fmt.Errorf("version_files[%d]: pattern %q matched nothing in %s", i, entry.Pattern, entry.Path)
+			}
+			continue
+		}
+		for _, constant := range entry.constants() {
+			if !found[constant.name] {
+				return nil, fmt.Errorf("version_files[%d]: constant '%s' not found in %s", i, constant.name, entry.Path)
+			}
+		}
+	}
+
+	if write {
+		if err := edits.write(); err != nil {
+			return nil, err
+		}
+	}
+	return replacements, nil
+}
+
+// fileEdits stages changes to files under a root so that several passes can
+// edit the same file and nothing is written until every pass succeeded.
+type fileEdits struct {
+	root     string
+	order    []string
+	contents map[string][]byte
+}
+
+func newFileEdits(root string) *fileEdits {
+	return &fileEdits{root: root, contents: make(map[string][]byte)}
+}
+
+// read returns the staged content of file, a slash-separated path relative
+// to the root, reading it on first use.
+func (e *fileEdits) read(file string) ([]byte, error) {
+	if content, ok := e.contents[file]; ok {
+		return content, nil
+	}
+	content, err := os.ReadFile(filepath.Join(e.root, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	e.order = append(e.order, file)
+	e.contents[file] = content
+	return content, nil
+}
+
+// set stages new content for a file previously read.
+func (e *fileEdits) set(file string, content []byte) {
+	e.contents[file] = content
+}
+
+// write writes every staged file, keeping its permissions.
+func (e *fileEdits) write() error {
+	for _, file := range e.order {
+		path := filepath.Join(e.root, filepath.FromSlash(file))
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		if err := os.WriteFile(path, e.contents[file], info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// replaceConstants sets every declaration of the named constants.
+func replaceConstants(content []byte, constants []versionConstant, data templateData) ([]byte, []VersionReplacement) {
+	var replacements []VersionReplacement
+	for _, constant := range constants {
+		pattern := constant.pattern()
+		var out bytes.Buffer
+		last := 0
+		for _, m := range pattern.FindAllSubmatchIndex(content, -1) {
+			old := string(content[m[6]:m[7]])
+			optional := m[4] >= 0 && m[5] > m[4]
+			value := constant.value(data, optional)
+
+			out.Write(content[last:m[6]])
+			out.WriteString(value)
+			last = m[7]
+
+			replacements = append(replacements, VersionReplacement{
+				Line: lineAt(content, m[6]),
+				Name: constant.name,
+				Old:  old,
+				New:  value,
+			})
+		}
+		out.Write(content[last:])
+		content = out.Bytes()
+	}
+	return content, replacements
+}
+
+// replacePattern replaces the entry pattern's matches with its template.
+func replacePattern(content []byte, entry VersionFileConfig, data templateData) ([]byte, []VersionReplacement, error) {
+	pattern, err := regexp.Compile(entry.Pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	rendered, err := renderTemplate("version_files.template", entry.Template, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var replacements []VersionReplacement
+	var out bytes.Buffer
+	last := 0
+	for _, m := range pattern.FindAllSubmatchIndex(content, -1) {
+		value := pattern.Expand(nil, []byte(rendered), content, m)
+		out.Write(content[last:m[0]])
+		out.Write(value)
+		last = m[1]
+
+		replacements = append(replacements, VersionReplacement{
+			Line: lineAt(content, m[0]),
+			Name: entry.Pattern,
+			Old:  string(content[m[0]:m[1]]),
+			New:  string(value),
+		})
+	}
+	out.Write(content[last:])
+	return out.Bytes(), replacements, nil
+}
+
+// lineAt returns the 1-based line number of offset.
+func lineAt(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+// globFiles returns the regular files under root whose slash-separated
+// relative paths match pattern, skipping .git and .build.
+func globFiles(root, pattern string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); p != root && (name == ".git" || name == ".build") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchGlob(pattern, rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// matchGlob matches a slash-separated path against a glob in which a **
+// segment matches zero or more path segments.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
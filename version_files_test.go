@@ -0,0 +1,217 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"Sources/Core/Version.swift", "Sources/Core/Version.swift", true},
+		{"Sources/*/Version.swift", "Sources/Core/Version.swift", true},
+		{"Sources/*/Version.swift", "Sources/Core/Sub/Version.swift", false},
+		{"Sources/**/Version.swift", "Sources/Version.swift", true},
+		{"Sources/**/Version.swift", "Sources/Core/Sub/Version.swift", true},
+		{"**/Version.swift", "Version.swift", true},
+		{"**/*.swift", "Sources/Core/a.swift", true},
+		{"Sources/**", "Sources/Core/a.swift", true},
+		{"Sources/**/Version.swift", "Tests/Core/Version.swift", false},
+		{"Version.swift", "Sources/Version.swift", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+const versionSource = `public enum KitVersion {
+    public static let version = "1.0.0"
+    public static let major = 1
+    public static let minor: Int = 0
+    public static var patch = 0
+    public static let prerelease: String? = nil
+    static let label: String = "stable"
+}
+`
+
+func TestStampVersionFiles_Constants(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Sources/Core/Version.swift":  versionSource,
+		"Sources/Extra/Version.swift": "let version = \"1.0.0\"\n",
+		"Sources/Core/Other.swift":    "let version = \"0.0.0\"\n",
+	})
+
+	replacements, err := StampVersionFiles(dir, []VersionFileConfig{
+		{
+			Path:       "Sources/**/Version.swift",
+			Constant:   "version",
+			Major:      "major",
+			Minor:      "minor",
+			Patch:      "patch",
+			Prerelease: "prerelease",
+		},
+		{Path: "Sources/Core/Version.swift", Prerelease: "label"},
+	}, newTemplateData("2.1.3-beta.2", ""), true)
+	if err != nil {
+		t.Fatalf("StampVersionFiles() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "Sources/Core/Version.swift"))
+	want := `public enum KitVersion {
+    public static let version = "2.1.3-beta.2"
+    public static let major = 2
+    public static let minor: Int = 1
+    public static var patch = 3
+    public static let prerelease: String? = "beta.2"
+    static let label: String = "beta.2"
+}
+`
+	if string(content) != want {
+		t.Errorf("Sources/Core/Version.swift =\n%s\nwant\n%s", content, want)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "Sources/Extra/Version.swift")); string(content) != "let version = \"2.1.3-beta.2\"\n" {
+		t.Errorf("Sources/Extra/Version.swift = %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "Sources/Core/Other.swift")); string(content) != "let version = \"0.0.0\"\n" {
+		t.Errorf("unmatched file was modified: %q", content)
+	}
+
+	if len(replacements) != 7 {
+		t.Fatalf("expected 7 replacements, got %d: %+v", len(replacements), replacements)
+	}
+	first := replacements[0]
+	if first.File != "Sources/Core/Version.swift" || first.Line != 2 || first.Name != "version" ||
+		first.Old != `"1.0.0"` || first.New != `"2.1.3-beta.2"` {
+		t.Errorf("unexpected first replacement %+v", first)
+	}
+}
+
+func TestStampVersionFiles_FinalReleaseClearsOptionalPrerelease(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Version.swift": "let prerelease: String? = \"beta.1\"\nlet channel = \"beta.1\"\n",
+	})
+
+	_, err := StampVersionFiles(dir, []VersionFileConfig{
+		{Path: "Version.swift", Prerelease: "prerelease"},
+		{Path: "Version.swift", Prerelease: "channel"},
+	}, newTemplateData("2.0.0", ""), true)
+	if err != nil {
+		t.Fatalf("StampVersionFiles() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "Version.swift"))
+	if want := "let prerelease: String? = nil\nlet channel = \"\"\n"; string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestStampVersionFiles_Pattern(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Sources/Kit/Info.swift": "// Kit 1.0.0 (tag v1.0.0)\nlet userAgent = \"Kit/1.0.0\"\n",
+	})
+
+	replacements, err := StampVersionFiles(dir, []VersionFileConfig{{
+		Path:     "Sources/**/Info.swift",
+		Pattern:  `(userAgent = "Kit)/[^"]*"`,
+		Template: `${1}/{{.Major}}.{{.Minor}} ({{.Tag}})"`,
+	}}, newTemplateData("3.4.5", "v"), true)
+	if err != nil {
+		t.Fatalf("StampVersionFiles() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "Sources/Kit/Info.swift"))
+	if want := "// Kit 1.0.0 (tag v1.0.0)\nlet userAgent = \"Kit/3.4 (v3.4.5)\"\n"; string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	if len(replacements) != 1 || replacements[0].Line != 2 || replacements[0].Old != `userAgent = "Kit/1.0.0"` {
+		t.Errorf("unexpected replacements %+v", replacements)
+	}
+}
+
+func TestStampVersionFiles_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   VersionFileConfig
+		wantErr string
+	}{
+		{"no matching files", VersionFileConfig{Path: "Sources/**/Missing.swift", Constant: "version"}, `no files match "Sources/**/Missing.swift"`},
+		{"missing constant", VersionFileConfig{Path: "Version.swift", Constant: "version", Major: "versionMajor"}, "constant 'versionMajor' not found"},
+		{"pattern without match", VersionFileConfig{Path: "Version.swift", Pattern: "build = \\d+", Template: "build = 1"}, "matched nothing"},
+		{"bad template field", VersionFileConfig{Path: "Version.swift", Pattern: "version", Template: "{{.Missing}}"}, "failed to render"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{"Version.swift": "let version = \"1.0.0\"\n"})
+
+			_, err := StampVersionFiles(dir, []VersionFileConfig{
+				{Path: "Version.swift", Constant: "version"},
+				tt.entry,
+			}, newTemplateData("2.0.0", ""), true)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			// Earlier entries are not written when a later one fails
+			if content, _ := os.ReadFile(filepath.Join(dir, "Version.swift")); string(content) != "let version = \"1.0.0\"\n" {
+				t.Errorf("file modified despite error: %q", content)
+			}
+		})
+	}
+}
+
+func TestStampVersionFiles_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"Version.swift": "let version = \"1.0.0\"\n"})
+
+	replacements, err := StampVersionFiles(dir, []VersionFileConfig{{Path: "Version.swift", Constant: "version"}},
+		newTemplateData("2.0.0", ""), false)
+	if err != nil {
+		t.Fatalf("StampVersionFiles() error = %v", err)
+	}
+	if len(replacements) != 1 || replacements[0].New != `"2.0.0"` {
+		t.Errorf("unexpected replacements %+v", replacements)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "Version.swift")); string(content) != "let version = \"1.0.0\"\n" {
+		t.Errorf("dry run modified the file: %q", content)
+	}
+}
+
+func TestVersionFileConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry VersionFileConfig
+		want  string
+	}{
+		{"constant", VersionFileConfig{Path: "Sources/**/Version.swift", Constant: "version"}, ""},
+		{"components only", VersionFileConfig{Path: "Version.swift", Major: "major"}, ""},
+		{"pattern", VersionFileConfig{Path: "Version.swift", Pattern: `v\d+`, Template: "v{{.Major}}"}, ""},
+		{"missing path", VersionFileConfig{Constant: "version"}, "requires path"},
+		{"nothing to update", VersionFileConfig{Path: "Version.swift"}, "requires constant"},
+		{"both", VersionFileConfig{Path: "Version.swift", Constant: "version", Pattern: "x", Template: "y"}, "both"},
+		{"pattern without template", VersionFileConfig{Path: "Version.swift", Pattern: "x"}, "requires template"},
+		{"bad pattern", VersionFileConfig{Path: "Version.swift", Pattern: "(", Template: "y"}, "invalid pattern"},
+		{"bad glob", VersionFileConfig{Path: "Sources/[", Constant: "version"}, "invalid path glob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.entry.validate()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
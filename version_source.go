@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionSourceConfig generates a Swift source file describing the release
+// into a target directory, so the shipped library can report which release
+// it was built from.
+type VersionSourceConfig struct {
+	// Directory is the target source directory, relative to the package
+	// root. Generation is disabled when it is empty.
+	Directory string `json:"directory"`
+	// FileName is the generated file name; defaults to Version.swift.
+	FileName string `json:"file_name"`
+	// TypeName is the name of the generated enum; defaults to PackageVersion.
+	TypeName string `json:"type_name"`
+	// Access is the access level of the enum and its members: public,
+	// package or internal. Defaults to public.
+	Access string `json:"access"`
+	// Template is an inline text/template replacing the built-in one.
+	Template string `json:"template"`
+	// TemplateFile is a template file, relative to the package root.
+	TemplateFile string `json:"template_file"`
+}
+
+// swiftIdentifier matches a plain Swift type name.
+var swiftIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validate reports a configuration problem, or "".
+func (c VersionSourceConfig) validate() string {
+	switch {
+	case c.Directory == "":
+		return ""
+	case c.FileName != "" && (filepath.Base(c.FileName) != c.FileName || !strings.HasSuffix(c.FileName, ".swift")):
+		return fmt.Sprintf("file_name %q must be a .swift file name", c.FileName)
+	case c.TypeName != "" && !swiftIdentifier.MatchString(c.TypeName):
+		return fmt.Sprintf("type_name %q is not a Swift identifier", c.TypeName)
+	case c.Access != "" && c.Access != "public" && c.Access != "package" && c.Access != "internal":
+		return fmt.Sprintf("access %q must be public, package or internal", c.Access)
+	case c.Template != "" && c.TemplateFile != "":
+		return "sets both template and template_file"
+	}
+	if c.Template != "" {
+		if _, err := newTemplate("version_source.template", c.Template); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// defaultVersionSourceTemplate renders an enum with the release details.
+const defaultVersionSourceTemplate = `// Generated by the Relicta swift-pm plugin. Do not edit.
+
+/// The release this package was built from.
+{{.Access}}enum {{.TypeName}} {
+    /// The semantic version, such as "1.2.3" or "2.0.0-beta.1".
+    {{.Access}}static let version = {{swiftString .Version}}
+    {{.Access}}static let major = {{.Major}}
+    {{.Access}}static let minor = {{.Minor}}
+    {{.Access}}static let patch = {{.Patch}}
+    {{.Access}}static let prerelease: String? = {{if .Prerelease}}{{swiftString .Prerelease}}{{else}}nil{{end}}
+    {{.Access}}static let buildMetadata: String? = {{if .Build}}{{swiftString .Build}}{{else}}nil{{end}}
+    /// The git commit the release was built from.
+    {{.Access}}static let commit = {{swiftString .Commit}}
+    /// The git tag of the release.
+    {{.Access}}static let tag = {{swiftString .Tag}}
+    /// The release date, in ISO 8601 format (YYYY-MM-DD).
+    {{.Access}}static let releaseDate = {{swiftString .Date}}
+}
+`
+
+// versionSourceData holds the fields available to version source templates.
+type versionSourceData struct {
+	templateData
+	// Commit is the full commit SHA and ShortCommit its first 12 characters.
+	Commit      string
+	ShortCommit string
+	// Date is the release date (YYYY-MM-DD) and Timestamp the release time
+	// in RFC 3339 format, both in UTC.
+	Date      string
+	Timestamp string
+	TypeName  string
+	// Access is the access modifier followed by a space, or empty for
+	// internal.
+	Access string
+}
+
+// newVersionSourceData returns the template fields for a release built from
+// commit at releaseTime.
+func newVersionSourceData(cfg VersionSourceConfig, data templateData, commit string, releaseTime time.Time) versionSourceData {
+	source := versionSourceData{
+		templateData: data,
+		Commit:       commit,
+		ShortCommit:  commit[:min(len(commit), 12)],
+		Date:         releaseTime.UTC().Format(time.DateOnly),
+		Timestamp:    releaseTime.UTC().Format(time.RFC3339),
+		TypeName:     cfg.TypeName,
+		Access:       cfg.Access,
+	}
+	if source.TypeName == "" {
+		source.TypeName = "PackageVersion"
+	}
+	switch source.Access {
+	case "":
+		source.Access = "public "
+	case "internal":
+		source.Access = ""
+	default:
+		source.Access += " "
+	}
+	return source
+}
+
+// GeneratedFile is a file written by the plugin.
+type GeneratedFile struct {
+	// Path is the slash-separated path relative to the package root.
+	Path    string `json:"path"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+	Content string `json:"-"`
+}
+
+// GenerateVersionSource renders the version source file into its target
+// directory under workDir. The file is only written when write is set.
+func GenerateVersionSource(workDir string, cfg VersionSourceConfig, data versionSourceData, write bool) (*GeneratedFile, error) {
+	text := defaultVersionSourceTemplate
+	if cfg.Template != "" {
+		text = cfg.Template
+	}
+	if cfg.TemplateFile != "" {
+		templatePath := cfg.TemplateFile
+		if !filepath.IsAbs(templatePath) {
+			templatePath = filepath.Join(workDir, templatePath)
+		}
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		text = string(content)
+	}
+
+	content, err := renderTemplate("version_source.template", text, data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(workDir, filepath.FromSlash(cfg.Directory))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("target directory %s not found", cfg.Directory)
+	}
+	fileName := cfg.FileName
+	if fileName == "" {
+		fileName = "Version.swift"
+	}
+	path := filepath.Join(dir, fileName)
+
+	if write {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil {
+		rel = path
+	}
+	return &GeneratedFile{
+		Path:    filepath.ToSlash(rel),
+		Commit:  data.Commit,
+		Date:    data.Date,
+		Content: content,
+	}, nil
+}
+
+// resolveCommit returns commit, or the HEAD commit of the repository at
+// workDir when it is empty.
+func resolveCommit(ctx context.Context, workDir, commit string) (string, error) {
+	if commit != "" {
+		return commit, nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = workDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// releaseTime returns the time recorded as the release date: the
+// SOURCE_DATE_EPOCH environment variable when set, for reproducible builds,
+// or the current time.
+func releaseTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", epoch)
+	}
+	return time.Unix(seconds, 0), nil
+}
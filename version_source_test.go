@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testReleaseTime = time.Date(2024, 12, 19, 15, 4, 5, 0, time.UTC)
+
+const testCommit = "4f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a3f"
+
+// TestGenerateVersionSource_Golden checks the built-in template output. Run
+// with -update to regenerate the golden files.
+func TestGenerateVersionSource_Golden(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     VersionSourceConfig
+		version string
+	}{
+		{"default", VersionSourceConfig{Directory: "Sources/Kit"}, "1.4.2"},
+		{"prerelease-internal", VersionSourceConfig{Directory: "Sources/Kit", TypeName: "KitVersion", Access: "internal"}, "2.0.0-beta.1+exp.sha.5114f85"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{"Sources/Kit/Kit.swift": "public struct Kit {}"})
+
+			data := newVersionSourceData(tt.cfg, newTemplateData(tt.version, "v"), testCommit, testReleaseTime)
+			file, err := GenerateVersionSource(dir, tt.cfg, data, true)
+			if err != nil {
+				t.Fatalf("GenerateVersionSource() error = %v", err)
+			}
+			if file.Path != "Sources/Kit/Version.swift" {
+				t.Errorf("path = %q", file.Path)
+			}
+
+			got, err := os.ReadFile(filepath.Join(dir, "Sources", "Kit", "Version.swift"))
+			if err != nil {
+				t.Fatalf("failed to read generated file: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "version-source", tt.name+".swift.golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update): %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("generated source mismatch:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestGenerateVersionSource_CustomTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"Sources/Kit/Kit.swift":         "public struct Kit {}",
+		"templates/BuildInfo.swift.tpl": "let buildInfo = {{swiftString .Version}} + \"@\" + {{swiftString .ShortCommit}} // {{.Timestamp}}\n",
+	})
+	data := newVersionSourceData(VersionSourceConfig{}, newTemplateData("1.0.0", ""), testCommit, testReleaseTime)
+
+	t.Run("inline", func(t *testing.T) {
+		cfg := VersionSourceConfig{Directory: "Sources/Kit", FileName: "Build.swift", Template: "let v = {{swiftString .Tag}}\n"}
+		file, err := GenerateVersionSource(dir, cfg, data, false)
+		if err != nil {
+			t.Fatalf("GenerateVersionSource() error = %v", err)
+		}
+		if file.Content != "let v = \"1.0.0\"\n" {
+			t.Errorf("content = %q", file.Content)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Sources", "Kit", "Build.swift")); !os.IsNotExist(err) {
+			t.Error("file written without write")
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		cfg := VersionSourceConfig{Directory: "Sources/Kit", TemplateFile: "templates/BuildInfo.swift.tpl"}
+		file, err := GenerateVersionSource(dir, cfg, data, true)
+		if err != nil {
+			t.Fatalf("GenerateVersionSource() error = %v", err)
+		}
+		want := "let buildInfo = \"1.0.0\" + \"@\" + \"4f2a9c1e8b7d\" // 2024-12-19T15:04:05Z\n"
+		if file.Content != want {
+			t.Errorf("content = %q, want %q", file.Content, want)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := GenerateVersionSource(dir, VersionSourceConfig{Directory: "Sources/Missing"}, data, true)
+		if err == nil || !strings.Contains(err.Error(), "Sources/Missing not found") {
+			t.Errorf("error = %v", err)
+		}
+	})
+}
+
+func TestVersionSourceConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  VersionSourceConfig
+		want string
+	}{
+		{"disabled", VersionSourceConfig{Access: "open"}, ""},
+		{"defaults", VersionSourceConfig{Directory: "Sources/Kit"}, ""},
+		{"file name with directory", VersionSourceConfig{Directory: "Sources/Kit", FileName: "Gen/Version.swift"}, "file_name"},
+		{"not a swift file", VersionSourceConfig{Directory: "Sources/Kit", FileName: "Version.txt"}, "file_name"},
+		{"bad type name", VersionSourceConfig{Directory: "Sources/Kit", TypeName: "Kit Version"}, "type_name"},
+		{"bad access", VersionSourceConfig{Directory: "Sources/Kit", Access: "open"}, "access"},
+		{"both templates", VersionSourceConfig{Directory: "Sources/Kit", Template: "x", TemplateFile: "y"}, "both"},
+		{"bad template", VersionSourceConfig{Directory: "Sources/Kit", Template: "{{.Version"}, "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.validate()
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseTime_SourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1734620645")
+	got, err := releaseTime()
+	if err != nil {
+		t.Fatalf("releaseTime() error = %v", err)
+	}
+	if !got.Equal(testReleaseTime) {
+		t.Errorf("releaseTime() = %v, want %v", got, testReleaseTime)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := releaseTime(); err == nil {
+		t.Error("expected error for invalid SOURCE_DATE_EPOCH")
+	}
+}
+
+func TestResolveCommit(t *testing.T) {
+	commit, err := resolveCommit(context.Background(), t.TempDir(), testCommit)
+	if err != nil || commit != testCommit {
+		t.Errorf("resolveCommit() = %q, %v; want the given commit", commit, err)
+	}
+
+	if _, err := resolveCommit(context.Background(), t.TempDir(), ""); err == nil {
+		t.Error("expected error outside a git repository")
+	}
+}